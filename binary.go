@@ -0,0 +1,305 @@
+package cimap
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+type (
+	// ValueCodec converts values of type T to and from bytes for
+	// [CaseInsensitiveMap.EncodeBinary] and [CaseInsensitiveMap.DecodeBinary].
+	//
+	// The encoder takes care of framing, so a codec only deals with a single value.
+	ValueCodec[T any] interface {
+		AppendValue(dst []byte, v T) ([]byte, error)
+		DecodeValue(data []byte) (T, error)
+	}
+
+	// StringCodec stores strings as their raw bytes.
+	StringCodec struct{}
+
+	// BytesCodec stores byte slices as is.
+	BytesCodec struct{}
+
+	// JSONCodec stores values as JSON.
+	JSONCodec[T any] struct{}
+
+	// BinaryOption configures [CaseInsensitiveMap.EncodeBinary] and
+	// [CaseInsensitiveMap.DecodeBinary].
+	BinaryOption func(*binaryOptions)
+
+	binaryOptions struct {
+		hashes bool
+		verify bool
+	}
+)
+
+const (
+	binaryVersion byte = 1
+
+	// binaryFlagHashes marks data that stores a precomputed hash after every key.
+	binaryFlagHashes byte = 1 << 0
+)
+
+var (
+	binaryMagic = [4]byte{'C', 'I', 'M', 'B'}
+
+	// ErrInvalidBinary is wrapped by every error returned while decoding malformed binary data.
+	ErrInvalidBinary = errors.New("cimap: invalid binary data")
+)
+
+// AppendValue implements [ValueCodec].
+func (StringCodec) AppendValue(dst []byte, v string) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+// DecodeValue implements [ValueCodec].
+func (StringCodec) DecodeValue(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// AppendValue implements [ValueCodec].
+func (BytesCodec) AppendValue(dst []byte, v []byte) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+// DecodeValue implements [ValueCodec].
+func (BytesCodec) DecodeValue(data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// AppendValue implements [ValueCodec].
+func (JSONCodec[T]) AppendValue(dst []byte, v T) ([]byte, error) {
+	b, err := json.Marshal(v)
+	return append(dst, b...), err
+}
+
+// DecodeValue implements [ValueCodec].
+func (JSONCodec[T]) DecodeValue(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// DefaultCodec returns the codec used by [CaseInsensitiveMap.MarshalBinary]:
+// [StringCodec] for strings, [BytesCodec] for byte slices and [JSONCodec] otherwise.
+func DefaultCodec[T any]() ValueCodec[T] {
+	switch any((*T)(nil)).(type) {
+	case *string:
+		return any(StringCodec{}).(ValueCodec[T])
+	case *[]byte:
+		return any(BytesCodec{}).(ValueCodec[T])
+	}
+	return JSONCodec[T]{}
+}
+
+// WithHashes stores the hash of every key so that [CaseInsensitiveMap.DecodeBinary]
+// can skip rehashing when the decoding map has no custom hasher.
+//
+// Hashes are never stored for maps using a hasher set with [CaseInsensitiveMap.SetHasher].
+func WithHashes() BinaryOption {
+	return func(o *binaryOptions) {
+		o.hashes = true
+	}
+}
+
+// VerifyHashes makes [CaseInsensitiveMap.DecodeBinary] rehash every key and
+// reject data whose stored hashes disagree with the folding mode. The checksum
+// only catches accidental damage, so use it for data from untrusted sources.
+//
+//	err := m.DecodeBinary(data, cimap.StringCodec{}, cimap.VerifyHashes())
+func VerifyHashes() BinaryOption {
+	return func(o *binaryOptions) {
+		o.verify = true
+	}
+}
+
+// MarshalBinary implements the [encoding.BinaryMarshaler] interface using [DefaultCodec].
+//
+//	data, err := m.MarshalBinary()
+func (c *CaseInsensitiveMap[T]) MarshalBinary() ([]byte, error) {
+	return c.EncodeBinary(DefaultCodec[T]())
+}
+
+// UnmarshalBinary implements the [encoding.BinaryUnmarshaler] interface using [DefaultCodec].
+//
+//	m := cimap.New[string]()
+//	err := m.UnmarshalBinary(data)
+func (c *CaseInsensitiveMap[T]) UnmarshalBinary(data []byte) error {
+	return c.DecodeBinary(data, DefaultCodec[T]())
+}
+
+// EncodeBinary encodes the map in a compact, versioned binary format.
+//
+// The data holds the folding mode name followed by length-prefixed keys and
+// values, in insertion order, and ends with a CRC-32 checksum. Values are
+// encoded with codec.
+//
+//	data, err := m.EncodeBinary(cimap.StringCodec{}, cimap.WithHashes())
+func (c *CaseInsensitiveMap[T]) EncodeBinary(codec ValueCodec[T], opts ...BinaryOption) ([]byte, error) {
+	var o binaryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var flags byte
+	if o.hashes && !c.customHash && c.hashString != nil {
+		flags |= binaryFlagHashes
+	}
+
+	folding := c.Folding()
+	buf := append([]byte(nil), binaryMagic[:]...)
+	buf = append(buf, binaryVersion, flags)
+	buf = binary.AppendUvarint(buf, uint64(len(folding)))
+	buf = append(buf, folding...)
+	buf = binary.AppendUvarint(buf, uint64(c.size))
+
+	var (
+		scratch []byte
+		err     error
+	)
+	for _, n := range c.nodes(OrderInsertion) {
+		buf = binary.AppendUvarint(buf, uint64(len(n.Key)))
+		buf = append(buf, n.Key...)
+		if flags&binaryFlagHashes != 0 {
+			buf = binary.LittleEndian.AppendUint64(buf, c.hashString(n.Key))
+		}
+
+		if scratch, err = codec.AppendValue(scratch[:0], n.Value); err != nil {
+			return nil, fmt.Errorf("cimap: encoding value of %q: %w", n.Key, err)
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(scratch)))
+		buf = append(buf, scratch...)
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// DecodeBinary decodes data produced by [CaseInsensitiveMap.EncodeBinary].
+//
+// Any existing data in the map is cleared. The folding mode is restored like
+// in [CaseInsensitiveMap.GobDecode]. Stored hashes are used instead of
+// rehashing keys whenever the map has no custom hasher; they are trusted
+// unless [VerifyHashes] is given. Malformed data returns an error wrapping
+// [ErrInvalidBinary].
+//
+//	m := cimap.New[string]()
+//	err := m.DecodeBinary(data, cimap.StringCodec{})
+func (c *CaseInsensitiveMap[T]) DecodeBinary(data []byte, codec ValueCodec[T], opts ...BinaryOption) error {
+	var o binaryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	const headerLen = len(binaryMagic) + 2
+	if len(data) < headerLen+4 {
+		return fmt.Errorf("%w: too short", ErrInvalidBinary)
+	}
+	body, trailer := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(trailer) {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidBinary)
+	}
+	if [4]byte(body[:4]) != binaryMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidBinary)
+	}
+	if body[4] != binaryVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBinary, body[4])
+	}
+	flags := body[5]
+	r := binaryReader{buf: body[headerLen:]}
+
+	folding := r.bytes()
+	count := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+	// every entry takes at least two length bytes, so larger counts are corrupt
+	if count > uint64(len(r.buf))/2 {
+		return fmt.Errorf("%w: entry count %d exceeds data size", ErrInvalidBinary, count)
+	}
+
+	c.reset(int(count))
+	if err := c.matchFolding(string(folding)); err != nil {
+		return err
+	}
+	useHashes := flags&binaryFlagHashes != 0 && !c.customHash
+
+	for i := uint64(0); i < count; i++ {
+		key := string(r.bytes())
+		var stored hash64
+		if flags&binaryFlagHashes != 0 {
+			stored = r.uint64()
+		}
+		val := r.bytes()
+		if r.err != nil {
+			return r.err
+		}
+		// a stored hash that disagrees with the folding would make the key unreachable
+		if useHashes && o.verify && stored != c.hashString(key) {
+			return fmt.Errorf("%w: stored hash of %q does not match its folding", ErrInvalidBinary, key)
+		}
+
+		v, err := codec.DecodeValue(val)
+		if err != nil {
+			return fmt.Errorf("cimap: decoding value of %q: %w", key, err)
+		}
+		if useHashes {
+			c.addHashed(stored, key, v)
+		} else {
+			c.Add(key, v)
+		}
+	}
+	if len(r.buf) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidBinary, len(r.buf))
+	}
+	return nil
+}
+
+// binaryReader reads the binary format, remembering the first error.
+type binaryReader struct {
+	buf []byte
+	err error
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: bad length", ErrInvalidBinary)
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 8 {
+		r.err = fmt.Errorf("%w: truncated hash", ErrInvalidBinary)
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+func (r *binaryReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("%w: length %d exceeds data size", ErrInvalidBinary, n)
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
@@ -0,0 +1,214 @@
+package cimap_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*cimap.CaseInsensitiveMap[int])(nil)
+	_ encoding.BinaryUnmarshaler = (*cimap.CaseInsensitiveMap[int])(nil)
+)
+
+func TestMarshalUnmarshalBinary(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	t.Run("Strings", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.Add("Hello", "World")
+		m.Add("ünïcode", "✓")
+
+		data, err := m.MarshalBinary()
+		assert.NoError(t, err)
+
+		m2 := cimap.New[string]()
+		assert.NoError(t, m2.UnmarshalBinary(data))
+		assert.Equal(t, 2, m2.Len())
+		val, ok := m2.Get("ÜNÏCODE")
+		assert.True(t, ok)
+		assert.Equal(t, "✓", val)
+	})
+
+	t.Run("JSON values", func(t *testing.T) {
+		m := cimap.New[point]()
+		m.Add("Origin", point{})
+		m.Add("One", point{1, 1})
+
+		data, err := m.MarshalBinary()
+		assert.NoError(t, err)
+
+		var m2 cimap.CaseInsensitiveMap[point]
+		assert.NoError(t, m2.UnmarshalBinary(data))
+		val, ok := m2.Get("one")
+		assert.True(t, ok)
+		assert.Equal(t, point{1, 1}, val)
+	})
+
+	t.Run("Precomputed hashes", func(t *testing.T) {
+		m := cimap.New[[]byte]()
+		m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+		m.Add("Content-Type", []byte("text/plain"))
+		m.Add("X-Request-Id", []byte("abc"))
+
+		data, err := m.EncodeBinary(cimap.BytesCodec{}, cimap.WithHashes())
+		assert.NoError(t, err)
+		plain, err := m.EncodeBinary(cimap.BytesCodec{})
+		assert.NoError(t, err)
+		assert.Len(t, data, len(plain)+2*8, "Expected one 8 byte hash per key")
+
+		m2 := cimap.New[[]byte]()
+		assert.NoError(t, m2.DecodeBinary(data, cimap.BytesCodec{}))
+		val, ok := m2.Get("contentType")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("text/plain"), val)
+		m2.Delete("x_request_id")
+		assert.Equal(t, 1, m2.Len())
+	})
+
+	t.Run("Wrong hash", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.Add("key", "value")
+		data, err := m.EncodeBinary(cimap.StringCodec{}, cimap.WithHashes())
+		assert.NoError(t, err)
+
+		valid := data
+
+		// flip a bit of the hash stored after the key and fix up the checksum
+		body := append([]byte(nil), data[:len(data)-4]...)
+		body[bytes.Index(body, []byte("key"))+3] ^= 1
+		data = withCRC(body)
+
+		// trusted by default, so the key lands in the wrong bucket
+		m2 := cimap.New[string]()
+		assert.NoError(t, m2.DecodeBinary(data, cimap.StringCodec{}))
+		assert.False(t, m2.Has("key"))
+
+		err = cimap.New[string]().DecodeBinary(data, cimap.StringCodec{}, cimap.VerifyHashes())
+		assert.ErrorIs(t, err, cimap.ErrInvalidBinary)
+		assert.ErrorContains(t, err, `stored hash of "key" does not match`)
+
+		m3 := cimap.New[string]()
+		assert.NoError(t, m3.DecodeBinary(valid, cimap.StringCodec{}, cimap.VerifyHashes()))
+		assert.True(t, m3.Has("KEY"))
+	})
+
+	t.Run("Custom hasher keeps its folding", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.SetFolding(cimap.FoldWidth())
+		m.Add("key", "value")
+		data, err := m.MarshalBinary()
+		assert.NoError(t, err)
+
+		m2 := cimap.New[string]()
+		m2.SetHasher(func(s string) uint64 { return uint64(len(s)) })
+		assert.ErrorIs(t, m2.UnmarshalBinary(data), cimap.ErrFoldingMismatch)
+		assert.Equal(t, "default", m2.Folding())
+	})
+
+	t.Run("Hashes skipped with custom hasher", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.SetHasher(func(s string) uint64 { return uint64(len(s)) })
+		m.Add("abc", "1")
+
+		withHashes, err := m.EncodeBinary(cimap.StringCodec{}, cimap.WithHashes())
+		assert.NoError(t, err)
+		plain, err := m.EncodeBinary(cimap.StringCodec{})
+		assert.NoError(t, err)
+		assert.Equal(t, plain, withHashes)
+	})
+
+	t.Run("Corrupt data", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.Add("key", "value")
+		data, err := m.MarshalBinary()
+		assert.NoError(t, err)
+
+		for i := range data {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] ^= 0xff
+			assert.ErrorIs(t, cimap.New[string]().UnmarshalBinary(corrupt), cimap.ErrInvalidBinary, "Byte %d", i)
+		}
+		assert.ErrorIs(t, cimap.New[string]().UnmarshalBinary(data[:5]), cimap.ErrInvalidBinary)
+	})
+}
+
+func TestUnmarshalBinary_Malformed(t *testing.T) {
+	// bodies with a valid checksum, so the parser itself has to reject them
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "Bad magic", body: "CIMX\x01\x00\x07default\x00", wantErr: "bad magic"},
+		{name: "Bad version", body: "CIMB\x02\x00\x07default\x00", wantErr: "unsupported version 2"},
+		{name: "Bad varint", body: "CIMB\x01\x00\xff", wantErr: "bad length"},
+		{name: "Folding too long", body: "CIMB\x01\x00\x10def", wantErr: "length 16 exceeds data size"},
+		{name: "Count too large", body: "CIMB\x01\x00\x07default\x05", wantErr: "entry count 5 exceeds data size"},
+		{name: "Key too long", body: "CIMB\x01\x00\x07default\x01\x05ab", wantErr: "length 5 exceeds data size"},
+		{name: "Truncated hash", body: "CIMB\x01\x01\x07default\x01\x01k\x00\x00", wantErr: "truncated hash"},
+		{name: "Missing value", body: "CIMB\x01\x00\x07default\x01\x01k", wantErr: "bad length"},
+		{name: "Trailing bytes", body: "CIMB\x01\x00\x07default\x00\x00", wantErr: "1 trailing bytes"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := cimap.New[string]().UnmarshalBinary(withCRC([]byte(tt.body)))
+			assert.ErrorIs(t, err, cimap.ErrInvalidBinary)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// withCRC appends the checksum trailer to an encoded body.
+func withCRC(body []byte) []byte {
+	return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+}
+
+// FuzzUnmarshalBinary fuzzes the body of the encoding and appends a valid
+// checksum, so mutations reach the parser instead of failing the CRC check.
+func FuzzUnmarshalBinary(f *testing.F) {
+	m := cimap.New[string]()
+	m.Add("Hello", "World")
+	m.Add("Foo", "")
+	for _, opts := range [][]cimap.BinaryOption{nil, {cimap.WithHashes()}} {
+		data, err := m.EncodeBinary(cimap.StringCodec{}, opts...)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data[:len(data)-4])
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		data := withCRC(append([]byte(nil), body...))
+		// trusted hashes may be inconsistent, but must never crash the decoder
+		_ = cimap.New[string]().UnmarshalBinary(data)
+
+		m := cimap.New[string]()
+		if err := m.DecodeBinary(data, cimap.StringCodec{}, cimap.VerifyHashes()); err != nil {
+			return
+		}
+
+		encoded, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatalf("re-encoding decoded map: %v", err)
+		}
+		m2 := cimap.New[string]()
+		if err := m2.UnmarshalBinary(encoded); err != nil {
+			t.Fatalf("decoding re-encoded map: %v", err)
+		}
+		if m.Len() != m2.Len() {
+			t.Fatalf("length changed from %d to %d", m.Len(), m2.Len())
+		}
+	})
+}
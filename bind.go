@@ -0,0 +1,436 @@
+package cimap
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// BindOption configures [Decode].
+	BindOption func(*bindOptions)
+
+	bindOptions struct {
+		unknownKeys bool
+	}
+
+	// BindError collects every value [Decode] could not store. Each error is a
+	// [*PathError] whose Path locates the value, such as "server.ports[1]".
+	BindError struct {
+		Errors []*PathError
+	}
+
+	// structField is a field of a struct reachable through [Decode] and [Encode].
+	structField struct {
+		name      string
+		index     []int
+		omitEmpty bool
+	}
+
+	// bindSource gives uniform access to the maps [Decode] reads from.
+	bindSource struct {
+		keys  []string
+		get   func(string) any
+		equal func(string, string) bool
+	}
+
+	// binder holds the state of a single [Decode] call.
+	binder struct {
+		opts bindOptions
+		errs []*PathError
+	}
+)
+
+// ErrUnknownKey is wrapped by the errors reported by [ErrorUnknownKeys].
+var ErrUnknownKey = errors.New("cimap: unknown key")
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// ErrorUnknownKeys makes [Decode] report keys that match no struct field.
+//
+//	err := cimap.Decode(cfg, &c, cimap.ErrorUnknownKeys())
+func ErrorUnknownKeys() BindOption {
+	return func(o *bindOptions) {
+		o.unknownKeys = true
+	}
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *BindError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Decode stores the values of m in the struct pointed to by dst.
+//
+// Keys are matched against field names, or the name given in a `cimap:"name"`
+// tag, using the folding of m; fields tagged `cimap:"-"` and unexported fields
+// are ignored. Fields of embedded structs are matched as if they belonged to
+// the outer struct. Nested maps, such as those produced by [DecodeNested], fill
+// nested structs and maps, and slices fill slices and arrays element by
+// element.
+//
+// Values are converted between numeric types, from strings to numbers and
+// bools, and to [time.Duration] from strings or nanoseconds. Strings are also
+// accepted by any field implementing [encoding.TextUnmarshaler]. Decoding
+// continues past bad values and returns a [*BindError] listing all of them.
+//
+//	type Config struct {
+//	    Port    int
+//	    Timeout time.Duration `cimap:"read_timeout"`
+//	}
+//	var cfg Config
+//	err := cimap.Decode(m, &cfg) // {"PORT": 8080, "Read_Timeout": "5s"}
+func Decode[T any](m *CaseInsensitiveMap[T], dst any, opts ...BindOption) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cimap: Decode requires a non-nil pointer to a struct, got %T", dst)
+	}
+
+	b := &binder{}
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	src, _ := newBindSource(m)
+	b.decodeStruct("", src, rv.Elem())
+	if len(b.errs) > 0 {
+		return &BindError{Errors: b.errs}
+	}
+	return nil
+}
+
+// newBindSource wraps a case-insensitive map or a Go map with string keys.
+func newBindSource(val any) (bindSource, bool) {
+	if m, ok := val.(anyLookup); ok {
+		return bindSource{
+			keys:  m.sortedKeys(),
+			get:   func(k string) any { v, _ := m.lookupAny(k); return v },
+			equal: m.equalKeys,
+		}, true
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return bindSource{}, false
+	}
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	slices.SortFunc(keys, compareFold)
+	return bindSource{
+		keys: keys,
+		get: func(k string) any {
+			return rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()
+		},
+		equal: strings.EqualFold,
+	}, true
+}
+
+func (c *CaseInsensitiveMap[T]) equalKeys(a, b string) bool {
+	return c.equalFold(a, b)
+}
+
+// fail records an error for the value at path.
+func (b *binder) fail(path string, err error) {
+	segment := path
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		segment = path[i+1:]
+	}
+	b.errs = append(b.errs, &PathError{Path: path, Segment: segment, Err: err})
+}
+
+// decodeStruct fills the fields of dst from src.
+func (b *binder) decodeStruct(path string, src bindSource, dst reflect.Value) {
+	fields := structFields(dst.Type())
+	for _, k := range src.keys {
+		i := slices.IndexFunc(fields, func(f structField) bool { return src.equal(f.name, k) })
+		if i < 0 {
+			if b.opts.unknownKeys {
+				b.fail(joinPath(path, k), ErrUnknownKey)
+			}
+			continue
+		}
+		b.decodeValue(joinPath(path, k), src.get(k), fieldByIndex(dst, fields[i].index))
+	}
+}
+
+// decodeValue converts val and stores it in dst.
+func (b *binder) decodeValue(path string, val any, dst reflect.Value) {
+	if val == nil {
+		return
+	}
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		b.decodeValue(path, val, dst.Elem())
+		return
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Type().AssignableTo(dst.Type()) {
+		dst.Set(rv)
+		return
+	}
+	if s, ok := val.(string); ok && dst.Type() != durationType && reflect.PointerTo(dst.Type()).Implements(textUnmarshalerType) {
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			b.fail(path, err)
+		}
+		return
+	}
+
+	if dst.Type() == durationType {
+		if d, ok := toDuration(val); ok {
+			dst.SetInt(int64(d))
+			return
+		}
+		b.typeError(path, dst, val)
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		switch v := val.(type) {
+		case string:
+			dst.SetString(v)
+		case json.Number:
+			dst.SetString(v.String())
+		case []byte:
+			dst.SetString(string(v))
+		default:
+			b.typeError(path, dst, val)
+		}
+	case reflect.Bool:
+		switch v := val.(type) {
+		case bool:
+			dst.SetBool(v)
+		case string:
+			bv, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				b.typeError(path, dst, val)
+				return
+			}
+			dst.SetBool(bv)
+		default:
+			b.typeError(path, dst, val)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt(val)
+		if !ok || dst.OverflowInt(int64(i)) {
+			b.typeError(path, dst, val)
+			return
+		}
+		dst.SetInt(int64(i))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := toInt(val)
+		if !ok || i < 0 || dst.OverflowUint(uint64(i)) {
+			b.typeError(path, dst, val)
+			return
+		}
+		dst.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(val)
+		if !ok || dst.OverflowFloat(f) {
+			b.typeError(path, dst, val)
+			return
+		}
+		dst.SetFloat(f)
+	case reflect.Slice, reflect.Array:
+		b.decodeList(path, val, dst)
+	case reflect.Map:
+		b.decodeMap(path, val, dst)
+	case reflect.Struct:
+		src, ok := newBindSource(val)
+		if !ok {
+			b.typeError(path, dst, val)
+			return
+		}
+		b.decodeStruct(path, src, dst)
+	default:
+		b.typeError(path, dst, val)
+	}
+}
+
+// decodeList fills a slice or array from a slice or array.
+func (b *binder) decodeList(path string, val any, dst reflect.Value) {
+	if s, ok := val.(string); ok && dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+		dst.SetBytes([]byte(s))
+		return
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		b.typeError(path, dst, val)
+		return
+	}
+
+	n := rv.Len()
+	if dst.Kind() == reflect.Slice {
+		dst.Set(reflect.MakeSlice(dst.Type(), n, n))
+	} else if n > dst.Len() {
+		b.fail(path, fmt.Errorf("%w: %d elements do not fit in %s", ErrPathType, n, dst.Type()))
+		return
+	}
+	for i := 0; i < n; i++ {
+		b.decodeValue(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface(), dst.Index(i))
+	}
+}
+
+// decodeMap fills a map with string keys from a map.
+func (b *binder) decodeMap(path string, val any, dst reflect.Value) {
+	src, ok := newBindSource(val)
+	if !ok || dst.Type().Key().Kind() != reflect.String {
+		b.typeError(path, dst, val)
+		return
+	}
+
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), len(src.keys)))
+	}
+	for _, k := range src.keys {
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		b.decodeValue(joinPath(path, k), src.get(k), elem)
+		dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+	}
+}
+
+func (b *binder) typeError(path string, dst reflect.Value, val any) {
+	b.fail(path, fmt.Errorf("%w: cannot convert %T to %s", ErrPathType, val, dst.Type()))
+}
+
+// toDuration converts strings and integers to a duration, like [GetDuration].
+func toDuration(val any) (time.Duration, bool) {
+	if s, ok := val.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil
+	}
+	n, ok := toInt(val)
+	return time.Duration(n), ok
+}
+
+// toFloat converts numeric values and numeric strings to a float64.
+func toFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// structFields returns the fields of the struct type t, including those
+// promoted from embedded structs. Outer fields hide embedded fields with an
+// equal name, like Go field selectors do.
+//
+// Embedded structs are walked breadth first and every type is expanded at
+// most once, as in encoding/json, so self-embedding types terminate.
+func structFields(t reflect.Type) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []structField
+	visited := map[reflect.Type]bool{}
+	next := []embedded{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		var level []structField
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				tag := f.Tag.Get("cimap")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				idx := append(slices.Clip(e.index), i)
+
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					// pointers to unexported structs cannot be allocated
+					if f.IsExported() || f.Type.Kind() != reflect.Pointer {
+						next = append(next, embedded{typ: ft, index: idx})
+					}
+					continue
+				}
+				if !f.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = f.Name
+				}
+				level = append(level, structField{name: name, index: idx, omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty")})
+			}
+		}
+
+		for _, f := range level {
+			if !slices.ContainsFunc(fields, func(other structField) bool { return strings.EqualFold(other.name, f.name) }) {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// fieldByIndex returns the nested field of v, allocating nil embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,205 @@
+package cimap_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	bindTLS struct {
+		CertFile string
+		Enabled  bool
+	}
+
+	bindServer struct {
+		Host    string
+		Port    uint16
+		Timeout time.Duration `cimap:"read_timeout"`
+		TLS     *bindTLS
+		Ignored string `cimap:"-"`
+	}
+
+	BindBase struct {
+		Name    string
+		Version int
+	}
+
+	bindConfig struct {
+		BindBase
+		Version  float64
+		Servers  []bindServer
+		Ports    [2]int
+		Labels   map[string]string
+		Limits   map[string]int
+		Tags     []string
+		Addr     net.IP
+		Extra    any
+		internal string
+	}
+
+	BindSelf struct {
+		*BindSelf
+		X int
+	}
+)
+
+func decodeConfig(t *testing.T, doc string) *cimap.CaseInsensitiveMap[any] {
+	t.Helper()
+	m := cimap.New[any]()
+	assert.NoError(t, m.DecodeJSON(strings.NewReader(doc), cimap.DecodeNested()))
+	return m
+}
+
+func TestDecode(t *testing.T) {
+	m := decodeConfig(t, `{
+		"NAME": "app",
+		"version": 1.5,
+		"servers": [{"HOST": "a", "port": "8080", "Read_Timeout": "5s", "tls": {"certfile": "a.pem", "ENABLED": "true"}}],
+		"ports": [80, 443],
+		"labels": {"Env": "prod"},
+		"limits": {"CPU": 2},
+		"tags": ["x", "y"],
+		"addr": "10.0.0.1",
+		"extra": {"Any": true},
+		"internal": "ignored"
+	}`)
+
+	var cfg bindConfig
+	assert.NoError(t, cimap.Decode(m, &cfg))
+
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, 0, cfg.BindBase.Version)
+	assert.Equal(t, 1.5, cfg.Version)
+	assert.Equal(t, []bindServer{{Host: "a", Port: 8080, Timeout: 5 * time.Second, TLS: &bindTLS{CertFile: "a.pem", Enabled: true}}}, cfg.Servers)
+	assert.Equal(t, [2]int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"Env": "prod"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"CPU": 2}, cfg.Limits)
+	assert.Equal(t, []string{"x", "y"}, cfg.Tags)
+	assert.Equal(t, "10.0.0.1", cfg.Addr.String())
+	assert.IsType(t, &cimap.CaseInsensitiveMap[any]{}, cfg.Extra)
+	assert.Equal(t, "", cfg.internal)
+}
+
+func TestDecode_Conversions(t *testing.T) {
+	type target struct {
+		I   int8
+		U   uint
+		F   float32
+		B   bool
+		S   string
+		D   time.Duration
+		Raw []byte
+	}
+
+	tests := []struct {
+		name string
+		in   map[string]any
+		want target
+	}{
+		{name: "Native", in: map[string]any{"i": int8(3), "u": uint(4), "f": float32(1.5), "b": true, "s": "x", "d": time.Second}, want: target{I: 3, U: 4, F: 1.5, B: true, S: "x", D: time.Second}},
+		{name: "JSON numbers", in: map[string]any{"I": 3.0, "U": 4.0, "F": 2.0, "D": 1000.0}, want: target{I: 3, U: 4, F: 2, D: time.Microsecond}},
+		{name: "Strings", in: map[string]any{"I": "-3", "U": " 4 ", "F": "0.25", "B": "1", "D": "1m", "Raw": "abc"}, want: target{I: -3, U: 4, F: 0.25, B: true, D: time.Minute, Raw: []byte("abc")}},
+		{name: "Nil values", in: map[string]any{"S": nil, "I": nil}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[any]()
+			for k, v := range tt.in {
+				m.Add(k, v)
+			}
+			var got target
+			assert.NoError(t, cimap.Decode(m, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	m := decodeConfig(t, `{
+		"name": 5,
+		"servers": [{"host": "a"}, {"port": 70000, "read_timeout": "soon", "tls": "yes"}],
+		"ports": [1, 2, 3],
+		"tags": "x",
+		"addr": "not-an-ip",
+		"unknown": 1,
+		"Labels": {"ok": "v", "bad": [1]}
+	}`)
+
+	var cfg bindConfig
+	err := cimap.Decode(m, &cfg, cimap.ErrorUnknownKeys())
+
+	var bindErr *cimap.BindError
+	assert.ErrorAs(t, err, &bindErr)
+	paths := make([]string, len(bindErr.Errors))
+	for i, e := range bindErr.Errors {
+		paths[i] = e.Path
+	}
+	assert.Equal(t, []string{
+		"addr",
+		"Labels.bad",
+		"name",
+		"ports",
+		"servers[1].port",
+		"servers[1].read_timeout",
+		"servers[1].tls",
+		"tags",
+		"unknown",
+	}, paths)
+
+	assert.ErrorIs(t, err, cimap.ErrUnknownKey)
+	assert.ErrorIs(t, err, cimap.ErrPathType)
+	assert.Contains(t, err.Error(), `cimap: path "servers[1].port": segment "port": cimap: unexpected type: cannot convert float64 to uint16`)
+
+	// values that could be decoded are kept
+	assert.Equal(t, "a", cfg.Servers[0].Host)
+	assert.Equal(t, "v", cfg.Labels["ok"])
+
+	t.Run("Unknown keys ignored by default", func(t *testing.T) {
+		var cfg bindConfig
+		err := cimap.Decode(decodeConfig(t, `{"unknown": 1}`), &cfg)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Bad destination", func(t *testing.T) {
+		for _, dst := range []any{nil, bindConfig{}, (*bindConfig)(nil), new(int)} {
+			err := cimap.Decode(cimap.New[any](), dst)
+			assert.ErrorContains(t, err, "requires a non-nil pointer to a struct")
+			assert.False(t, errors.As(err, new(*cimap.BindError)))
+		}
+	})
+}
+
+func TestDecode_Folding(t *testing.T) {
+	type target struct {
+		RequestID string `cimap:"request-id"`
+		MaxConns  int
+	}
+
+	m := cimap.New[string]()
+	m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+	m.Add("REQUEST_ID", "abc")
+	m.Add("max_conns", "10")
+
+	var got target
+	assert.NoError(t, cimap.Decode(m, &got))
+	assert.Equal(t, target{RequestID: "abc", MaxConns: 10}, got)
+}
+
+func TestDecode_SelfEmbedding(t *testing.T) {
+	m := cimap.New[any]()
+	m.Add("x", 1)
+
+	var got BindSelf
+	assert.NoError(t, cimap.Decode(m, &got))
+	assert.Equal(t, 1, got.X)
+	assert.Nil(t, got.BindSelf)
+}
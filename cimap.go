@@ -1,7 +1,11 @@
 package cimap
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"iter"
 	"strings"
 	"unicode"
@@ -14,6 +18,7 @@ type (
 		Value T
 		Key   string
 		Next  *node[T]
+		Seq   uint64 // insertion sequence, kept when the value is replaced
 	}
 
 	// [CaseInsensitiveMap] is a generic map that performs case-insensitive key comparisons.
@@ -23,10 +28,19 @@ type (
 	CaseInsensitiveMap[T any] struct {
 		size        int
 		hashString  func(string) hash64
+		equalFold   func(string, string) bool
+		folding     *folder
+		customHash  bool
+		check       *consistencyCheck
+		counters    *opCounters
+		nextSeq     uint64
 		internalMap map[hash64]*node[T]
 	}
 )
 
+// ErrFoldingMismatch is returned when two maps with different folding modes are combined.
+var ErrFoldingMismatch = errors.New("cimap: folding modes do not match")
+
 const (
 	offset64 = hash64(14695981039346656037)
 	prime64  = hash64(1099511628211)
@@ -43,11 +57,13 @@ func New[T any](size ...int) *CaseInsensitiveMap[T] {
 		return &CaseInsensitiveMap[T]{
 			internalMap: make(map[hash64]*node[T], size[0]),
 			hashString:  defaultHashString,
+			equalFold:   strings.EqualFold,
 		}
 	}
 	return &CaseInsensitiveMap[T]{
 		internalMap: make(map[hash64]*node[T]),
 		hashString:  defaultHashString,
+		equalFold:   strings.EqualFold,
 	}
 }
 
@@ -60,13 +76,27 @@ func New[T any](size ...int) *CaseInsensitiveMap[T] {
 //	m.Add("Hello", "World")
 //	m.Add("hello", "Gophers")
 func (c *CaseInsensitiveMap[T]) Add(k string, val T) {
-	if n, ok := c.internalMap[c.hashString(k)]; ok {
-		if !n.insertOrReplace(k, val) {
+	if c.counters != nil {
+		c.counters.adds.Add(1)
+	}
+	if c.check != nil {
+		c.check.sample(k, c.hashString, c.equalFold)
+	}
+	c.addHashed(c.hashString(k), k, val)
+}
+
+// addHashed inserts or updates k using a hash that was already computed for it.
+// Unlike Add it neither counts the operation nor samples the key.
+func (c *CaseInsensitiveMap[T]) addHashed(h hash64, k string, val T) {
+	if n, ok := c.internalMap[h]; ok {
+		if !n.insertOrReplace(k, val, c.nextSeq, c.equalFold) {
+			c.nextSeq++
 			c.size++
 		}
 	} else {
-		newNode := node[T]{Value: val, Key: k}
-		c.internalMap[c.hashString(k)] = &newNode
+		newNode := node[T]{Value: val, Key: k, Seq: c.nextSeq}
+		c.internalMap[h] = &newNode
+		c.nextSeq++
 		c.size++
 	}
 }
@@ -81,17 +111,58 @@ func (c *CaseInsensitiveMap[T]) Add(k string, val T) {
 //	m.Add("Key", 42)
 //	value, ok := m.Get("key") // Output: 42 true
 func (c *CaseInsensitiveMap[T]) Get(k string) (T, bool) {
+	if c.counters != nil {
+		c.counters.gets.Add(1)
+	}
 	for n := c.internalMap[c.hashString(k)]; n != nil; n = n.Next {
-		if !strings.EqualFold(n.Key, k) {
+		if !c.equalFold(n.Key, k) {
 			continue
 		}
 		return n.Value, true
 	}
 
+	if c.counters != nil {
+		c.counters.misses.Add(1)
+	}
 	var def T
 	return def, false
 }
 
+// Lookup returns the value associated with the specified key using a case-insensitive
+// comparison, or the zero value of T if the key is not present.
+//
+// Unlike [CaseInsensitiveMap.Get] it returns a single value, so it can be called from templates.
+//
+//	m := cimap.New[string]()
+//	m.Add("Content-Type", "text/html")
+//	value := m.Lookup("content-type") // Output: "text/html"
+//	// {{ .Headers.Lookup "content-type" }}
+func (c *CaseInsensitiveMap[T]) Lookup(k string) T {
+	v, _ := c.Get(k)
+	return v
+}
+
+// Has reports whether the specified key is present using a case-insensitive comparison.
+//
+//	m := cimap.New[int]()
+//	m.Add("Key", 42)
+//	ok := m.Has("KEY") // Output: true
+//	// {{ if .Headers.Has "authorization" }}...{{ end }}
+func (c *CaseInsensitiveMap[T]) Has(k string) bool {
+	_, ok := c.Get(k)
+	return ok
+}
+
+// getNode returns the node stored for k, or nil if the key is not present.
+func (c *CaseInsensitiveMap[T]) getNode(k string) *node[T] {
+	for n := c.internalMap[c.hashString(k)]; n != nil; n = n.Next {
+		if c.equalFold(n.Key, k) {
+			return n
+		}
+	}
+	return nil
+}
+
 // GetAndDel retrieves the value associated with the specified key and then removes the key-value pair from the map.
 //
 // It returns the value and a boolean indicating whether the key was found.
@@ -138,10 +209,24 @@ func (c *CaseInsensitiveMap[T]) GetOrSet(k string, val T) T {
 //	m.Delete("DELETE")
 //	m.Get("delete") // Output: false
 func (c *CaseInsensitiveMap[T]) Delete(k string) {
-	if n, ok := c.internalMap[c.hashString(k)]; ok && n.delete(k) {
-		delete(c.internalMap, c.hashString(k))
-		c.size--
+	if c.counters != nil {
+		c.counters.deletes.Add(1)
+	}
+	h := c.hashString(k)
+	n, ok := c.internalMap[h]
+	if !ok {
+		return
+	}
+	head, deleted := n.delete(k, c.equalFold)
+	if !deleted {
+		return
+	}
+	if head == nil {
+		delete(c.internalMap, h)
+	} else {
+		c.internalMap[h] = head
 	}
+	c.size--
 }
 
 // Len returns the number of key-value pairs currently stored in the map.
@@ -165,6 +250,19 @@ func (c *CaseInsensitiveMap[T]) Clear() {
 	c.size = 0
 }
 
+// reset empties the map, preallocating room for size keys, and installs the
+// default hash and equality functions on a zero value map.
+func (c *CaseInsensitiveMap[T]) reset(size int) {
+	c.internalMap = make(map[hash64]*node[T], size)
+	c.size = 0 // it's 0 since we are going to remove elements by cases collision
+	if c.hashString == nil {
+		c.hashString = defaultHashString
+	}
+	if c.equalFold == nil {
+		c.equalFold = strings.EqualFold
+	}
+}
+
 // Keys returns an iterator over all keys stored in the map.
 // The iteration order is unspecified.
 //
@@ -215,7 +313,9 @@ func (c *CaseInsensitiveMap[T]) Iterator() iter.Seq2[string, T] {
 // and the map is rehashed immediately to reflect the new hashing strategy.
 //
 // WARNING(a1): Don't use this unless you know what you are doing. This function
-// can destroy the performance of this module if not used correctly.
+// can destroy the performance of this module if not used correctly. A hasher that
+// disagrees with key equality corrupts the map; use
+// [CaseInsensitiveMap.SetConsistencyCheck] to catch it while testing.
 //
 //	customHasher := func(s string) uint64 {
 //	    return uint64(len(s))
@@ -223,13 +323,24 @@ func (c *CaseInsensitiveMap[T]) Iterator() iter.Seq2[string, T] {
 //	m.SetHasher(customHasher)
 func (c *CaseInsensitiveMap[T]) SetHasher(hashString func(string) hash64) {
 	c.hashString = hashString
+	c.customHash = true
 	// we need to rehash the map
-	if c.size > 0 {
-		newMap := make(map[hash64]*node[T], c.size)
-		for _, v := range c.internalMap {
-			newMap[hashString(v.Key)] = v
-		}
-		c.internalMap = newMap
+	c.rehash()
+}
+
+// rehash rebuilds the internal map using the current hash and equality functions.
+//
+// Entries are re-added in insertion order, so keys that become equal under the
+// new functions are merged into the most recently inserted entry.
+func (c *CaseInsensitiveMap[T]) rehash() {
+	if c.size == 0 {
+		return
+	}
+	nodes := c.nodes(OrderInsertion)
+	c.internalMap = make(map[hash64]*node[T], c.size)
+	c.size = 0
+	for _, v := range nodes {
+		c.addHashed(c.hashString(v.Key), v.Key, v.Value)
 	}
 }
 
@@ -252,6 +363,27 @@ func (c *CaseInsensitiveMap[T]) ForEach(fn func(string, T) bool) {
 	}
 }
 
+// Merge copies every key-value pair of other into the map.
+//
+// Keys already present are replaced, keeping the casing from other.
+// Both maps must use the same folding mode, otherwise [ErrFoldingMismatch] is
+// returned and the map is left untouched.
+//
+//	a := cimap.New[int]()
+//	b := cimap.New[int]()
+//	b.Add("Key", 1)
+//	err := a.Merge(b) // Output: <nil>
+func (c *CaseInsensitiveMap[T]) Merge(other *CaseInsensitiveMap[T]) error {
+	if c.Folding() != other.Folding() {
+		return ErrFoldingMismatch
+	}
+	other.ForEach(func(k string, v T) bool {
+		c.Add(k, v)
+		return true
+	})
+	return nil
+}
+
 // UnmarshalJSON implements the [json.Unmarshaler] interface.
 //
 // It decodes JSON data into the map using case-insensitive key handling.
@@ -263,18 +395,12 @@ func (c *CaseInsensitiveMap[T]) ForEach(fn func(string, T) bool) {
 //	    log.Fatal(err)
 //	}
 func (c *CaseInsensitiveMap[T]) UnmarshalJSON(data []byte) error {
-	var m map[string]T
-	if err := json.Unmarshal(data, &m); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := c.decodeJSON(dec, newDecodeOptions(nil)); err != nil {
 		return err
 	}
-
-	c.internalMap = make(map[hash64]*node[T], len(m))
-	c.size = 0 // it's 0 since we are going to remove elements by cases collision
-	if c.hashString == nil {
-		c.hashString = defaultHashString
-	}
-	for k, v := range m {
-		c.Add(k, v)
+	if _, err := dec.Token(); err != io.EOF {
+		return &DecodeError{Offset: dec.InputOffset(), Err: errors.New("unexpected data after top-level value")}
 	}
 	return nil
 }
@@ -282,18 +408,50 @@ func (c *CaseInsensitiveMap[T]) UnmarshalJSON(data []byte) error {
 // MarshalJSON implements the [json.Marshaler] interface.
 //
 // It encodes the map into JSON format, preserving the original casing of keys.
+// Keys are written in [OrderSorted] so the output is deterministic.
 //
-//   data, err := json.Marshal(m) // Output: {"Key":123}
-
+//	data, err := json.Marshal(m) // Output: {"Key":123}
 func (c *CaseInsensitiveMap[T]) MarshalJSON() ([]byte, error) {
-	m := make(map[string]T, c.size)
-	for _, v := range c.internalMap {
-		for ; v != nil; v = v.Next {
-			m[v.Key] = v.Value
-		}
+	var buf bytes.Buffer
+	if err := c.MarshalJSONTo(&buf); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	return json.Marshal(m)
+// MarshalJSONTo streams the map to w as a JSON object without building an
+// intermediate Go map.
+//
+// Keys are written in the given [Order], defaulting to [OrderSorted].
+//
+//	var buf bytes.Buffer
+//	err := m.MarshalJSONTo(&buf, cimap.OrderInsertion)
+func (c *CaseInsensitiveMap[T]) MarshalJSONTo(w io.Writer, order ...Order) error {
+	o := OrderSorted
+	if len(order) > 0 {
+		o = order[0]
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	for i, n := range c.nodes(o) {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		key, err := json.Marshal(n.Key)
+		if err != nil {
+			return err
+		}
+		val, err := json.Marshal(n.Value)
+		if err != nil {
+			return err
+		}
+		bw.Write(key)
+		bw.WriteByte(':')
+		bw.Write(val)
+	}
+	bw.WriteByte('}')
+	return bw.Flush()
 }
 
 ////////////////////////////////////////////////////////////
@@ -316,35 +474,37 @@ func defaultHashString(key string) hash64 {
 // NODE METHODS
 ////////////////////////////////////////////////////////////
 
-func (n *node[T]) delete(key string) bool {
-	if strings.EqualFold(n.Key, key) {
-		n = n.Next
-		return true
+// delete removes key from the chain starting at n.
+//
+// It returns the new head of the chain and true if the key was found.
+func (n *node[T]) delete(key string, equal func(string, string) bool) (*node[T], bool) {
+	if equal(n.Key, key) {
+		return n.Next, true
 	}
 	for prev := n; prev.Next != nil; prev = prev.Next {
-		if strings.EqualFold(prev.Next.Key, key) {
+		if equal(prev.Next.Key, key) {
 			prev.Next = prev.Next.Next
-			return true
+			return n, true
 		}
 	}
-	return false
+	return n, false
 }
 
 // make a node function called insert or replace which uses key to insert or replace a node
 // loop through the linked list and if the key exists, replace the node
-// if the key does not exist, insert a new node
+// if the key does not exist, insert a new node with the given sequence
 //
 // return true if the node existed
-func (n *node[T]) insertOrReplace(key string, val T) bool {
+func (n *node[T]) insertOrReplace(key string, val T, seq uint64, equal func(string, string) bool) bool {
 	var prev *node[T] = nil
 	for cur := n; cur != nil; prev, cur = cur, cur.Next {
-		if !strings.EqualFold(cur.Key, key) {
+		if !equal(cur.Key, key) {
 			continue
 		}
 		cur.Key = key
 		cur.Value = val
 		return true
 	}
-	prev.Next = &node[T]{Key: key, Value: val}
+	prev.Next = &node[T]{Key: key, Value: val, Seq: seq}
 	return false
 }
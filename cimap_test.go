@@ -178,6 +178,28 @@ func TestGetAndDel(t *testing.T) {
 	}
 }
 
+func TestLookup_Has(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want keyAssert
+	}{
+		{name: "Same case", key: "Hello", want: keyAssert{"Hello", "World", true}},
+		{name: "Different case", key: "hELLO", want: keyAssert{"hELLO", "World", true}},
+		{name: "Missing key", key: "Bye", want: keyAssert{"Bye", "", false}},
+	}
+
+	m := cimap.New[string]()
+	m.Add("Hello", "World")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.val, m.Lookup(tt.key))
+			assert.Equal(t, tt.want.expected, m.Has(tt.key))
+		})
+	}
+}
+
 func TestGetOrSet(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -533,4 +555,98 @@ func TestMarshalUnmarshalJSON(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("Marshal error", func(t *testing.T) {
+		m := cimap.New[func()]()
+		m.Add("fn", func() {})
+		_, err := json.Marshal(m)
+		assert.Error(t, err)
+	})
+}
+
+func TestMarshalJSONTo(t *testing.T) {
+	m := cimap.New[int]()
+	m.Add("b", 1)
+	m.Add("Ä", 2)
+	m.Add("C", 3)
+	m.Add("a", 4)
+	m.Add("B", 5) // replaces "b" but keeps its insertion position
+
+	tests := []struct {
+		name     string
+		order    []cimap.Order
+		expected string
+	}{
+		{
+			name:     "Default is sorted",
+			expected: `{"a":4,"B":5,"C":3,"Ä":2}`,
+		},
+		{
+			name:     "Sorted",
+			order:    []cimap.Order{cimap.OrderSorted},
+			expected: `{"a":4,"B":5,"C":3,"Ä":2}`,
+		},
+		{
+			name:     "Insertion",
+			order:    []cimap.Order{cimap.OrderInsertion},
+			expected: `{"B":5,"Ä":2,"C":3,"a":4}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			assert.NoError(t, m.MarshalJSONTo(&buf, tt.order...))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	t.Run("Stored", func(t *testing.T) {
+		var buf strings.Builder
+		assert.NoError(t, m.MarshalJSONTo(&buf, cimap.OrderStored))
+		assert.JSONEq(t, `{"a":4,"B":5,"C":3,"Ä":2}`, buf.String())
+	})
+
+	t.Run("Matches json.Marshal", func(t *testing.T) {
+		encoded, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":4,"B":5,"C":3,"Ä":2}`, string(encoded))
+	})
+
+	t.Run("Empty map", func(t *testing.T) {
+		var buf strings.Builder
+		assert.NoError(t, cimap.New[int]().MarshalJSONTo(&buf))
+		assert.Equal(t, `{}`, buf.String())
+	})
+
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("Same folding", func(t *testing.T) {
+		a := cimap.New[string]()
+		a.Add("Foo", "1")
+		a.Add("Bar", "2")
+
+		b := cimap.New[string]()
+		b.Add("FOO", "3")
+		b.Add("Baz", "4")
+
+		assert.NoError(t, a.Merge(b))
+		assert.Equal(t, 3, a.Len())
+		val, ok := a.Get("foo")
+		assert.True(t, ok)
+		assert.Equal(t, "3", val, "Expected merged value to replace the existing one")
+	})
+
+	t.Run("Different folding", func(t *testing.T) {
+		a := cimap.New[string]()
+		a.Add("Foo", "1")
+
+		b := cimap.New[string]()
+		b.SetFolding(cimap.FoldTurkish())
+		b.Add("Bar", "2")
+
+		assert.ErrorIs(t, a.Merge(b), cimap.ErrFoldingMismatch)
+		assert.Equal(t, 1, a.Len(), "Expected map to be untouched after a failed merge")
+	})
 }
@@ -0,0 +1,95 @@
+package cimap
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// ConsistencyError reports two keys that the map considers equal but that
+	// hash to different buckets.
+	//
+	// Such a pair silently breaks lookups: the second key is stored next to the
+	// first instead of replacing it. It usually means a hasher installed with
+	// [CaseInsensitiveMap.SetHasher] disagrees with the active folding.
+	ConsistencyError struct {
+		A, B         string
+		HashA, HashB uint64
+	}
+
+	// consistencyCheck samples added keys and verifies hash/equality agreement.
+	consistencyCheck struct {
+		every  int
+		count  int
+		report func(error)
+		recent [consistencyRecent]string
+		next   int
+	}
+)
+
+// consistencyRecent is the number of previously sampled keys each sample is compared against.
+const consistencyRecent = 8
+
+func (e *ConsistencyError) Error() string {
+	return fmt.Sprintf("cimap: keys %q and %q are equal but hash differently (%#x != %#x)", e.A, e.B, e.HashA, e.HashB)
+}
+
+// SetConsistencyCheck enables a debug mode that verifies the hash function agrees
+// with key equality.
+//
+// Every n-th call to Add checks the added key against its upper and lower case
+// forms and against recently sampled keys. When two keys are equal but hash
+// differently, report is called with a [*ConsistencyError]; a nil report panics
+// instead. Passing n <= 0 disables the check.
+//
+// WARNING(a1): The check allocates and hashes several extra strings per sample,
+// keep it out of hot paths in production.
+//
+//	m := cimap.New[int]()
+//	m.SetConsistencyCheck(1, func(err error) {
+//	    log.Println(err)
+//	})
+func (c *CaseInsensitiveMap[T]) SetConsistencyCheck(n int, report func(error)) {
+	if n <= 0 {
+		c.check = nil
+		return
+	}
+	c.check = &consistencyCheck{every: n, report: report}
+}
+
+// sample checks key if it is due for sampling.
+func (cc *consistencyCheck) sample(key string, hash func(string) hash64, equal func(string, string) bool) {
+	cc.count++
+	if cc.count < cc.every {
+		return
+	}
+	cc.count = 0
+
+	h := hash(key)
+	candidates := [...]string{strings.ToUpper(key), strings.ToLower(key)}
+	for _, other := range candidates {
+		cc.compare(key, h, other, hash, equal)
+	}
+	for _, other := range cc.recent {
+		if other != "" {
+			cc.compare(key, h, other, hash, equal)
+		}
+	}
+
+	cc.recent[cc.next] = key
+	cc.next = (cc.next + 1) % consistencyRecent
+}
+
+// compare reports a violation if key and other are equal but hash differently.
+func (cc *consistencyCheck) compare(key string, h hash64, other string, hash func(string) hash64, equal func(string, string) bool) {
+	if !equal(key, other) {
+		return
+	}
+	if oh := hash(other); oh != h {
+		err := &ConsistencyError{A: key, B: other, HashA: h, HashB: oh}
+		if cc.report == nil {
+			panic(err)
+		}
+		cc.report(err)
+	}
+}
@@ -0,0 +1,87 @@
+package cimap_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetConsistencyCheck(t *testing.T) {
+	// caseSensitive hashes the raw bytes, so "Key" and "KEY" land in different buckets
+	caseSensitive := func(s string) uint64 {
+		var h uint64
+		for _, r := range s {
+			h = h*31 + uint64(r)
+		}
+		return h
+	}
+
+	t.Run("Consistent hasher", func(t *testing.T) {
+		var errs []error
+		m := cimap.New[int]()
+		m.SetConsistencyCheck(1, func(err error) {
+			errs = append(errs, err)
+		})
+		for _, k := range []string{"Key", "KEY", "other", "Ünïcödé"} {
+			m.Add(k, 1)
+		}
+		assert.Empty(t, errs)
+	})
+
+	t.Run("Inconsistent hasher reports", func(t *testing.T) {
+		var errs []error
+		m := cimap.New[int]()
+		m.SetHasher(caseSensitive)
+		m.SetConsistencyCheck(1, func(err error) {
+			errs = append(errs, err)
+		})
+		m.Add("Key", 1)
+
+		assert.NotEmpty(t, errs)
+		var cerr *cimap.ConsistencyError
+		assert.True(t, errors.As(errs[0], &cerr))
+		assert.Equal(t, "Key", cerr.A)
+		assert.True(t, strings.EqualFold(cerr.A, cerr.B))
+	})
+
+	t.Run("Inconsistent hasher panics", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.SetHasher(caseSensitive)
+		m.SetConsistencyCheck(1, nil)
+		assert.Panics(t, func() {
+			m.Add("Key", 1)
+		})
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		calls := 0
+		m := cimap.New[int]()
+		m.SetHasher(caseSensitive)
+		m.SetConsistencyCheck(3, func(err error) {
+			calls++
+		})
+		m.Add("a", 1)
+		m.Add("b", 2)
+		assert.Zero(t, calls, "Expected no check before the third Add")
+		m.Add("c", 3)
+		assert.NotZero(t, calls)
+
+		m.SetConsistencyCheck(0, nil)
+		assert.NotPanics(t, func() {
+			m.Add("D", 4)
+		})
+	})
+
+	t.Run("Rehash not sampled", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.Add("Key", 1)
+		m.SetConsistencyCheck(1, nil)
+		assert.NotPanics(t, func() {
+			m.SetHasher(caseSensitive)
+		})
+	})
+}
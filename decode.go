@@ -0,0 +1,123 @@
+package cimap
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// DecodeOption configures decoders such as [CaseInsensitiveMap.DecodeJSON].
+	DecodeOption func(*decodeOptions)
+
+	decodeOptions struct {
+		sizeHint  int
+		collision CollisionPolicy
+		nested    bool
+	}
+
+	// CollisionPolicy decides what a decoder does when a document contains two
+	// keys that differ only by case, such as "Name" and "name".
+	CollisionPolicy uint8
+
+	// DecodeError describes a decoding failure and where in the input it happened.
+	DecodeError struct {
+		Offset int64  // byte offset in the input, for formats that report one
+		Line   int    // 1-based line in the input, for formats that report one
+		Column int    // 1-based column in the input, for formats that report one
+		Key    string // key being decoded, if any
+		Err    error
+	}
+)
+
+const (
+	// CollisionReplace keeps the value of the last key, matching [CaseInsensitiveMap.Add].
+	CollisionReplace CollisionPolicy = iota
+	// CollisionKeepFirst keeps the value and casing of the first key.
+	CollisionKeepFirst
+	// CollisionError aborts decoding with an error wrapping [ErrKeyCollision].
+	CollisionError
+)
+
+// ErrKeyCollision is returned by decoders using [CollisionError] when two keys
+// are equal ignoring case.
+var ErrKeyCollision = errors.New("cimap: key collision")
+
+// WithSizeHint preallocates room for n keys before decoding.
+//
+//	err := m.DecodeJSON(r, cimap.WithSizeHint(1_000_000))
+func WithSizeHint(n int) DecodeOption {
+	return func(o *decodeOptions) {
+		o.sizeHint = n
+	}
+}
+
+// WithCollisionPolicy sets how keys that differ only by case are handled.
+// The default is [CollisionReplace].
+//
+//	err := m.DecodeJSON(r, cimap.WithCollisionPolicy(cimap.CollisionError))
+func WithCollisionPolicy(p CollisionPolicy) DecodeOption {
+	return func(o *decodeOptions) {
+		o.collision = p
+	}
+}
+
+// DecodeNested turns every object at any depth into a [*CaseInsensitiveMap][any]
+// that shares the folding of the map being decoded, so nested lookups are
+// case-insensitive too. Arrays become []any and other values decode as they
+// would into an any.
+//
+// It requires a map with values of type any.
+//
+//	cfg := cimap.New[any]()
+//	err := cfg.DecodeJSON(r, cimap.DecodeNested())
+//	port, ok := cfg.Path("Server", "PORT")
+func DecodeNested() DecodeOption {
+	return func(o *decodeOptions) {
+		o.nested = true
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) decodeOptions {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// validate checks that the options can be used to decode into target.
+func (o decodeOptions) validate(target any) error {
+	if _, ok := target.(*CaseInsensitiveMap[any]); o.nested && !ok {
+		return &DecodeError{Err: errors.New("nested decoding requires a CaseInsensitiveMap[any]")}
+	}
+	return nil
+}
+
+func (e *DecodeError) Error() string {
+	pos := fmt.Sprintf("offset %d", e.Offset)
+	if e.Line > 0 {
+		pos = fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	}
+	if e.Key != "" {
+		return fmt.Sprintf("cimap: decoding key %q at %s: %v", e.Key, pos, e.Err)
+	}
+	return fmt.Sprintf("cimap: decoding at %s: %v", pos, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// addWithPolicy adds k unless it collides with an existing key and the policy says otherwise.
+func (c *CaseInsensitiveMap[T]) addWithPolicy(k string, v T, policy CollisionPolicy) error {
+	if policy != CollisionReplace {
+		if n := c.getNode(k); n != nil {
+			if policy == CollisionError {
+				return fmt.Errorf("%w: %q and %q", ErrKeyCollision, n.Key, k)
+			}
+			return nil
+		}
+	}
+	c.Add(k, v)
+	return nil
+}
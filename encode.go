@@ -0,0 +1,166 @@
+package cimap
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+var (
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// encoder holds the state of a single [Encode] call.
+type encoder struct {
+	// seen holds the pointers and slices on the path to the current value.
+	seen map[any]struct{}
+}
+
+// Encode converts the struct, or pointer to struct, src into a map.
+//
+// It is the reverse of [Decode]: keys are the field names, or the name given
+// in a `cimap:"name"` tag, in field order. Fields tagged `cimap:"-"` and
+// unexported fields are skipped, and fields tagged `cimap:",omitempty"` are
+// skipped when empty, as in encoding/json. Fields of embedded structs are
+// stored as if they belonged to the outer struct. Nested structs become nested
+// maps, including inside slices and arrays, unless they implement
+// [encoding.TextMarshaler] or [json.Marshaler]; other values are stored as is.
+// Like encoding/json, it returns an error when src refers to itself.
+//
+//	defaults, _ := cimap.Encode(Config{Port: 8080})
+//	overrides, _ := cimap.Encode(flags)
+//	_ = defaults.Merge(overrides)
+func Encode(src any) (*CaseInsensitiveMap[any], error) {
+	rv := reflect.ValueOf(src)
+	e := &encoder{seen: make(map[any]struct{})}
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		e.seen[src] = struct{}{}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cimap: Encode requires a struct or a non-nil pointer to a struct, got %T", src)
+	}
+	return e.encodeStruct(rv)
+}
+
+// encodeStruct converts the fields of v into a new map.
+func (e *encoder) encodeStruct(v reflect.Value) (*CaseInsensitiveMap[any], error) {
+	fields := slices.Clone(structFields(v.Type()))
+	slices.SortFunc(fields, func(a, b structField) int { return slices.Compare(a.index, b.index) })
+
+	m := New[any](len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndexNoAlloc(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		val, err := e.encodeValue(fv)
+		if err != nil {
+			return nil, err
+		}
+		m.Add(f.name, val)
+	}
+	return m, nil
+}
+
+// encodeValue converts structs found in v into maps.
+func (e *encoder) encodeValue(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(textMarshalerType) || v.Type().Implements(jsonMarshalerType) {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return v.Interface(), nil
+		}
+		elem := v.Elem()
+		if elem.Kind() != reflect.Struct && v.Kind() != reflect.Interface {
+			break
+		}
+		if v.Kind() == reflect.Interface {
+			return e.encodeValue(elem)
+		}
+		// typed pointers keep a struct apart from its first field
+		return e.visit(v.Interface(), v, func() (any, error) { return e.encodeValue(elem) })
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Slice, reflect.Array:
+		if !containsStructs(v.Type().Elem()) || v.Kind() == reflect.Slice && v.IsNil() {
+			break
+		}
+		encode := func() (any, error) {
+			list := make([]any, v.Len())
+			for i := range list {
+				val, err := e.encodeValue(v.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				list[i] = val
+			}
+			return list, nil
+		}
+		if v.Kind() == reflect.Array {
+			return encode()
+		}
+		// slices sharing a backing array only cycle if they also share a length
+		key := struct {
+			ptr uintptr
+			len int
+		}{v.Pointer(), v.Len()}
+		return e.visit(key, v, encode)
+	}
+	return v.Interface(), nil
+}
+
+// visit runs encode with key marked as seen, failing if it already is.
+func (e *encoder) visit(key any, v reflect.Value, encode func() (any, error)) (any, error) {
+	if _, ok := e.seen[key]; ok {
+		return nil, fmt.Errorf("cimap: Encode encountered a cycle via %s", v.Type())
+	}
+	e.seen[key] = struct{}{}
+	defer delete(e.seen, key)
+	return encode()
+}
+
+// containsStructs reports whether values of type t may need to be converted into maps.
+func containsStructs(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Interface
+}
+
+// fieldByIndexNoAlloc is like [fieldByIndex] but reports false when it meets a
+// nil embedded pointer.
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is empty in the sense of the omitempty option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
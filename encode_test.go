@@ -0,0 +1,180 @@
+package cimap_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	encodeTLS struct {
+		CertFile string `cimap:"cert_file"`
+	}
+
+	EncodeMeta struct {
+		Owner string
+		Team  string `cimap:",omitempty"`
+	}
+
+	encodeConfig struct {
+		*EncodeMeta
+		Name     string
+		Port     int           `cimap:"port,omitempty"`
+		Timeout  time.Duration `cimap:",omitempty"`
+		TLS      *encodeTLS
+		Backends []encodeTLS
+		Tags     []string `cimap:",omitempty"`
+		Started  time.Time
+		Any      any
+		Secret   string `cimap:"-"`
+		internal int
+	}
+
+	encodeNode struct {
+		Name string
+		Next *encodeNode
+		Any  any
+	}
+)
+
+func TestEncode(t *testing.T) {
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		src  any
+		want string
+	}{
+		{
+			name: "Zero value",
+			src:  encodeConfig{},
+			want: `{"Name":"","TLS":null,"Backends":null,"Started":"0001-01-01T00:00:00Z","Any":null}`,
+		},
+		{
+			name: "Full",
+			src: &encodeConfig{
+				EncodeMeta: &EncodeMeta{Owner: "ops"},
+				Name:       "api",
+				Port:       8080,
+				Timeout:    time.Second,
+				TLS:        &encodeTLS{CertFile: "a.pem"},
+				Backends:   []encodeTLS{{CertFile: "b.pem"}},
+				Tags:       []string{"x"},
+				Started:    started,
+				Any:        encodeTLS{CertFile: "c.pem"},
+				Secret:     "hidden",
+				internal:   1,
+			},
+			want: `{"Owner":"ops","Name":"api","port":8080,"Timeout":1000000000,"TLS":{"cert_file":"a.pem"},"Backends":[{"cert_file":"b.pem"}],"Tags":["x"],"Started":"2024-01-02T03:04:05Z","Any":{"cert_file":"c.pem"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := cimap.Encode(tt.src)
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			assert.NoError(t, m.MarshalJSONTo(&sb, cimap.OrderInsertion))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+
+	t.Run("Nested maps", func(t *testing.T) {
+		m, err := cimap.Encode(encodeConfig{TLS: &encodeTLS{CertFile: "a.pem"}})
+		assert.NoError(t, err)
+		cert, err := cimap.GetString(m, "tls.CERT_FILE")
+		assert.NoError(t, err)
+		assert.Equal(t, "a.pem", cert)
+	})
+
+	t.Run("Not a struct", func(t *testing.T) {
+		for _, src := range []any{nil, 1, map[string]any{}, (*encodeConfig)(nil)} {
+			_, err := cimap.Encode(src)
+			assert.ErrorContains(t, err, "requires a struct")
+		}
+	})
+}
+
+func TestEncode_MergeDecode(t *testing.T) {
+	defaults, err := cimap.Encode(encodeConfig{Name: "api", Port: 80, TLS: &encodeTLS{CertFile: "default.pem"}})
+	assert.NoError(t, err)
+
+	overrides := cimap.New[any]()
+	assert.NoError(t, overrides.DecodeJSON(strings.NewReader(`{"PORT": 8080, "tls": {"CERT_FILE": "prod.pem"}}`), cimap.DecodeNested()))
+	assert.NoError(t, defaults.Merge(overrides))
+
+	var got encodeConfig
+	assert.NoError(t, cimap.Decode(defaults, &got))
+	assert.Equal(t, "api", got.Name)
+	assert.Equal(t, 8080, got.Port)
+	assert.Equal(t, &encodeTLS{CertFile: "prod.pem"}, got.TLS)
+}
+
+func TestEncode_Cycles(t *testing.T) {
+	self := &encodeNode{Name: "self"}
+	self.Next = self
+
+	loop := &encodeNode{Name: "a", Next: &encodeNode{Name: "b"}}
+	loop.Next.Next = loop
+
+	list := []any{nil}
+	list[0] = list
+
+	shared := &encodeNode{Name: "shared"}
+
+	type outer struct {
+		Inner encodeTLS
+		Ptr   *encodeTLS
+	}
+	first := &outer{}
+	first.Ptr = &first.Inner
+
+	tests := []struct {
+		name string
+		src  any
+		err  bool
+	}{
+		{
+			name: "Self reference",
+			src:  self,
+			err:  true,
+		},
+		{
+			name: "Cycle below a value",
+			src:  encodeNode{Next: loop},
+			err:  true,
+		},
+		{
+			name: "Slice containing itself",
+			src:  encodeNode{Any: list},
+			err:  true,
+		},
+		{
+			name: "Shared pointer",
+			src:  encodeNode{Next: shared, Any: shared},
+		},
+		{
+			name: "Pointer to the first field",
+			src:  first,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := cimap.Encode(tt.src)
+			if tt.err {
+				assert.ErrorContains(t, err, "encountered a cycle")
+				assert.Nil(t, m)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
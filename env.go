@@ -0,0 +1,61 @@
+package cimap
+
+import (
+	"os"
+	"strings"
+)
+
+// FromEnviron builds a map from "KEY=value" entries in the form returned by
+// [os.Environ], comparing variable names case-insensitively like Windows does.
+//
+// Entries without a '=' are skipped. A '=' at the start of an entry belongs to
+// the name, so Windows drive entries such as "=C:=C:\dir" are kept. Names that
+// only differ in case are handled by the [CollisionPolicy] given with
+// [WithCollisionPolicy]; the default keeps the last one. Collision errors are
+// returned as a [*DecodeError] whose Line is the 1-based index of the entry.
+//
+//	env, err := cimap.FromEnviron(os.Environ())
+//	env.Lookup("path") // Output: "/usr/local/bin:/usr/bin"
+func FromEnviron(environ []string, opts ...DecodeOption) (*CaseInsensitiveMap[string], error) {
+	o := newDecodeOptions(opts)
+	m := New[string](max(o.sizeHint, len(environ)))
+	if err := o.validate(m); err != nil {
+		return nil, err
+	}
+
+	for i, kv := range environ {
+		eq := strings.IndexByte(kv, '=')
+		if eq == 0 {
+			eq = strings.IndexByte(kv[1:], '=') + 1
+		}
+		if eq <= 0 {
+			continue
+		}
+		k, v := kv[:eq], kv[eq+1:]
+		if err := m.addWithPolicy(k, v, o.collision); err != nil {
+			return m, &DecodeError{Line: i + 1, Column: 1, Key: k, Err: err}
+		}
+	}
+	return m, nil
+}
+
+// Environ returns the variables of m as "KEY=value" entries, sorted
+// case-insensitively, ready to be used as [os/exec.Cmd.Env].
+//
+//	cmd.Env = cimap.Environ(env)
+func Environ(m *CaseInsensitiveMap[string]) []string {
+	environ := make([]string, 0, m.Len())
+	for _, n := range m.nodes(OrderSorted) {
+		environ = append(environ, n.Key+"="+n.Value)
+	}
+	return environ
+}
+
+// Expand replaces ${var} and $var in s with the values of m, looking names up
+// case-insensitively. Undefined variables are replaced by the empty string.
+//
+//	env, _ := cimap.FromEnviron([]string{"Path=/bin"})
+//	cimap.Expand(env, "PATH=${PATH}:$HOME") // Output: "PATH=/bin:"
+func Expand(m *CaseInsensitiveMap[string], s string) string {
+	return os.Expand(s, m.Lookup)
+}
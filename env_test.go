@@ -0,0 +1,105 @@
+package cimap_test
+
+import (
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnviron(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		opts    []cimap.DecodeOption
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "Simple",
+			environ: []string{"PATH=/bin", "HOME=/root", "EMPTY="},
+			want:    []string{"EMPTY=", "HOME=/root", "PATH=/bin"},
+		},
+		{
+			name:    "Value with equals",
+			environ: []string{"OPTS=-a=1 -b=2"},
+			want:    []string{"OPTS=-a=1 -b=2"},
+		},
+		{
+			name:    "Windows drive entry",
+			environ: []string{`=C:=C:\dir`, "=", "=C:", "invalid"},
+			want:    []string{`=C:=C:\dir`},
+		},
+		{
+			name:    "Replace",
+			environ: []string{"PATH=/bin", "Path=/usr/bin"},
+			want:    []string{"Path=/usr/bin"},
+		},
+		{
+			name:    "Keep first",
+			environ: []string{"PATH=/bin", "Path=/usr/bin"},
+			opts:    []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionKeepFirst)},
+			want:    []string{"PATH=/bin"},
+		},
+		{
+			name:    "Error",
+			environ: []string{"HOME=/root", "PATH=/bin", "path=/usr/bin"},
+			opts:    []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionError)},
+			wantErr: `cimap: decoding key "path" at line 3, column 1: cimap: key collision: "PATH" and "path"`,
+		},
+		{
+			name:    "Nested rejected",
+			environ: []string{"A=1"},
+			opts:    []cimap.DecodeOption{cimap.DecodeNested()},
+			wantErr: "nested decoding requires a CaseInsensitiveMap[any]",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := cimap.FromEnviron(tt.environ, tt.opts...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.ErrorAs(t, err, new(*cimap.DecodeError))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cimap.Environ(m))
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	m := cimap.New[string]()
+	m.Add("b", "2")
+	m.Add("A", "1")
+	m.Add("Path", "/bin")
+	assert.Equal(t, []string{"A=1", "b=2", "Path=/bin"}, cimap.Environ(m))
+	assert.Empty(t, cimap.Environ(cimap.New[string]()))
+}
+
+func TestExpand(t *testing.T) {
+	env, err := cimap.FromEnviron([]string{"Path=/bin", "home=/root", "Name=world"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Braces", in: "${PATH}:${path}", want: "/bin:/bin"},
+		{name: "Bare", in: "$HOME/.config", want: "/root/.config"},
+		{name: "Undefined", in: "[$MISSING]", want: "[]"},
+		{name: "Mixed", in: "hello ${name}, cd $Home", want: "hello world, cd /root"},
+		{name: "No variables", in: "plain text", want: "plain text"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cimap.Expand(env, tt.in))
+		})
+	}
+}
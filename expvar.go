@@ -0,0 +1,136 @@
+package cimap
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// Counters holds the number of operations performed on a map since
+	// [CaseInsensitiveMap.EnableCounters] was called.
+	Counters struct {
+		Adds    uint64 `json:"adds"`
+		Gets    uint64 `json:"gets"`
+		Misses  uint64 `json:"misses"` // Gets that did not find the key
+		Deletes uint64 `json:"deletes"`
+	}
+
+	opCounters struct {
+		adds, gets, misses, deletes atomic.Uint64
+	}
+
+	// ExpvarOption configures [CaseInsensitiveMap.Expvar].
+	ExpvarOption func(*expvarVar)
+
+	// expvarVar renders a map for expvar on demand.
+	expvarVar struct {
+		render func() any
+		locker sync.Locker
+		stats  bool
+	}
+
+	expvarStats struct {
+		Entries      json.RawMessage `json:"entries"`
+		Len          int             `json:"len"`
+		Buckets      int             `json:"buckets"`
+		Collisions   int             `json:"collisions"`
+		LongestChain int             `json:"longest_chain"`
+		Folding      string          `json:"folding"`
+		Counters     *Counters       `json:"counters,omitempty"`
+	}
+)
+
+// EnableCounters starts counting Add, Get and Delete calls.
+//
+// Counters are updated atomically, so they can be read with
+// [CaseInsensitiveMap.Counters] from another goroutine. Calling it again resets them.
+//
+//	m.EnableCounters()
+//	m.Get("missing")
+//	m.Counters() // Output: {Adds:0 Gets:1 Misses:1 Deletes:0}
+func (c *CaseInsensitiveMap[T]) EnableCounters() {
+	c.counters = &opCounters{}
+}
+
+// Counters returns the operation counters, or the zero value if counting is disabled.
+func (c *CaseInsensitiveMap[T]) Counters() Counters {
+	if c.counters == nil {
+		return Counters{}
+	}
+	return Counters{
+		Adds:    c.counters.adds.Load(),
+		Gets:    c.counters.gets.Load(),
+		Misses:  c.counters.misses.Load(),
+		Deletes: c.counters.deletes.Load(),
+	}
+}
+
+// ExpvarStats adds the length, [Stats], folding mode and, when enabled, the
+// [Counters] of the map next to its entries.
+func ExpvarStats() ExpvarOption {
+	return func(v *expvarVar) {
+		v.stats = true
+	}
+}
+
+// ExpvarLocker makes the variable hold l while it reads the map. Pass the lock
+// that guards the map, such as mu.RLocker() for a sync.RWMutex, when the map
+// is modified by other goroutines.
+func ExpvarLocker(l sync.Locker) ExpvarOption {
+	return func(v *expvarVar) {
+		v.locker = l
+	}
+}
+
+// Expvar returns an [expvar.Var] that renders the map as JSON every time it is read.
+//
+//	var mu sync.RWMutex
+//	flags := cimap.New[bool]()
+//	expvar.Publish("flags", flags.Expvar(cimap.ExpvarStats(), cimap.ExpvarLocker(mu.RLocker())))
+func (c *CaseInsensitiveMap[T]) Expvar(opts ...ExpvarOption) expvar.Var {
+	v := &expvarVar{}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	v.render = func() any {
+		if !v.stats {
+			return c
+		}
+		entries, err := c.MarshalJSON()
+		if err != nil {
+			return map[string]string{"error": err.Error()}
+		}
+		s := c.Stats()
+		out := expvarStats{
+			Entries:      entries,
+			Len:          s.Len,
+			Buckets:      s.Buckets,
+			Collisions:   s.Collisions,
+			LongestChain: s.LongestChain,
+			Folding:      c.Folding(),
+		}
+		if c.counters != nil {
+			counters := c.Counters()
+			out.Counters = &counters
+		}
+		return out
+	}
+	return v
+}
+
+// String implements the [expvar.Var] interface.
+func (v *expvarVar) String() string {
+	if v.locker != nil {
+		v.locker.Lock()
+		defer v.locker.Unlock()
+	}
+
+	data, err := json.Marshal(v.render())
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return string(data)
+}
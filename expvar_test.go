@@ -0,0 +1,113 @@
+package cimap_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounters(t *testing.T) {
+	m := cimap.New[int]()
+	assert.Equal(t, cimap.Counters{}, m.Counters())
+
+	m.Add("a", 1)
+	m.EnableCounters()
+	m.Add("B", 2)
+	m.Add("b", 3)
+	m.Get("A")
+	m.Get("missing")
+	m.Delete("a")
+	assert.Equal(t, cimap.Counters{Adds: 2, Gets: 2, Misses: 1, Deletes: 1}, m.Counters())
+
+	m.EnableCounters()
+	assert.Equal(t, cimap.Counters{}, m.Counters())
+
+	m.Add("c", 4)
+	m.SetFolding(cimap.FoldWidth())
+	m.SetHasher(func(s string) uint64 { return uint64(len(s)) })
+	assert.Equal(t, cimap.Counters{Adds: 1}, m.Counters(), "Expected rehashing not to count as adds")
+}
+
+func TestExpvar(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []cimap.ExpvarOption
+		counters bool
+		want     string
+	}{
+		{
+			name: "Entries",
+			want: `{"a":1,"B":2}`,
+		},
+		{
+			name: "Stats",
+			opts: []cimap.ExpvarOption{cimap.ExpvarStats()},
+			want: `{"entries":{"a":1,"B":2},"len":2,"buckets":2,"collisions":0,"longest_chain":1,"folding":"default"}`,
+		},
+		{
+			name:     "Counters",
+			opts:     []cimap.ExpvarOption{cimap.ExpvarStats()},
+			counters: true,
+			want:     `{"entries":{"a":1,"B":2},"len":2,"buckets":2,"collisions":0,"longest_chain":1,"folding":"default","counters":{"adds":2,"gets":0,"misses":0,"deletes":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[int]()
+			if tt.counters {
+				m.EnableCounters()
+			}
+			m.Add("B", 2)
+			m.Add("a", 1)
+			assert.JSONEq(t, tt.want, m.Expvar(tt.opts...).String())
+		})
+	}
+
+	t.Run("Live", func(t *testing.T) {
+		m := cimap.New[string]()
+		v := m.Expvar()
+		assert.Equal(t, `{}`, v.String())
+		m.Add("Key", "value")
+		assert.Equal(t, `{"Key":"value"}`, v.String())
+	})
+
+	t.Run("Locker", func(t *testing.T) {
+		var mu sync.RWMutex
+		m := cimap.New[int]()
+		m.EnableCounters()
+		v := m.Expvar(cimap.ExpvarStats(), cimap.ExpvarLocker(mu.RLocker()))
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				mu.Lock()
+				m.Add("key", i)
+				mu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				assert.True(t, json.Valid([]byte(v.String())))
+			}
+		}()
+		wg.Wait()
+		assert.Equal(t, uint64(100), m.Counters().Adds)
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		m := cimap.New[bool]()
+		m.Add("Feature", true)
+		expvar.Publish("cimap-test-flags", m.Expvar())
+		assert.Equal(t, `{"Feature":true}`, expvar.Get("cimap-test-flags").String())
+	})
+}
@@ -0,0 +1,468 @@
+package cimap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+type (
+	// FoldOption configures how keys are folded before they are hashed and compared.
+	//
+	// Options are applied with [CaseInsensitiveMap.SetFolding].
+	FoldOption func(*folder)
+
+	// folder holds the active folding options of a map.
+	//
+	// The same folder is used for hashing and for equality so both always agree.
+	folder struct {
+		normalizer     KeyNormalizer
+		normalizerName string
+		nfc            bool
+		special        unicode.SpecialCase
+		specialName    string
+		separators     Separators
+		width          bool
+	}
+
+	// foldIter walks the folded runes of a key without allocating.
+	//
+	// Enabled separator runes are skipped.
+	foldIter struct {
+		f *folder
+		s string
+	}
+
+	// KeyNormalizer rewrites keys before they are folded.
+	//
+	// The map uses the normalized key for both hashing and equality, so two
+	// keys are the same whenever their normalized forms fold to the same runes.
+	// Normalization must be deterministic.
+	KeyNormalizer interface {
+		NormalizeKey(key string) string
+	}
+
+	// KeyNormalizerFunc adapts an ordinary function to a [KeyNormalizer].
+	KeyNormalizerFunc func(key string) string
+
+	// Separators is a set of word separators that [FoldSeparators] ignores.
+	Separators uint8
+)
+
+const (
+	// SeparatorHyphen ignores '-'.
+	SeparatorHyphen Separators = 1 << iota
+	// SeparatorUnderscore ignores '_'.
+	SeparatorUnderscore
+	// SeparatorDot ignores '.'.
+	SeparatorDot
+	// SeparatorSpace ignores any Unicode white space.
+	SeparatorSpace
+	// SeparatorCamelCase ignores camelCase transitions, such as the one between
+	// "content" and "Type". A transition has no rune of its own, so case folding
+	// already covers it; the flag only records the intent in the folding name.
+	SeparatorCamelCase
+
+	// SeparatorAll enables every separator.
+	SeparatorAll = SeparatorHyphen | SeparatorUnderscore | SeparatorDot | SeparatorSpace | SeparatorCamelCase
+)
+
+type separatorName struct {
+	sep    Separators
+	name   string
+	goName string
+}
+
+var separatorNames = []separatorName{
+	{SeparatorHyphen, "hyphen", "SeparatorHyphen"},
+	{SeparatorUnderscore, "underscore", "SeparatorUnderscore"},
+	{SeparatorDot, "dot", "SeparatorDot"},
+	{SeparatorSpace, "space", "SeparatorSpace"},
+	{SeparatorCamelCase, "camel", "SeparatorCamelCase"},
+}
+
+// DefaultFolding is the name reported by [CaseInsensitiveMap.Folding] when no
+// folding options are active.
+const DefaultFolding = "default"
+
+// NormalizeKey calls f(key).
+func (f KeyNormalizerFunc) NormalizeKey(key string) string {
+	return f(key)
+}
+
+// FoldNormalizer runs n over every key before the remaining folding steps.
+//
+// The name identifies the normalizer in [CaseInsensitiveMap.Folding] so maps
+// using different normalizers are never mixed.
+//
+//	trim := cimap.KeyNormalizerFunc(func(k string) string {
+//	    return strings.TrimPrefix(strings.TrimSpace(k), "X-")
+//	})
+//	m.SetFolding(cimap.FoldNormalizer("trim-x", trim))
+//	m.Add("X-Request-Id", "abc")
+//	m.Get(" request-id ") // Output: abc true
+func FoldNormalizer(name string, n KeyNormalizer) FoldOption {
+	return func(f *folder) {
+		f.normalizer = n
+		f.normalizerName = name
+	}
+}
+
+// FoldNFC makes hashing and comparison aware of Unicode canonical equivalence.
+//
+// Keys are compared in Normalization Form C, so "é" written as U+00E9 and as
+// "e" followed by U+0301 are the same key. Keys that are already in NFC skip
+// the normalization step entirely.
+//
+//	m := cimap.New[int]()
+//	m.SetFolding(cimap.FoldNFC())
+//	m.Add("café", 1)
+//	m.Get("CAFÉ") // Output: 1 true
+func FoldNFC() FoldOption {
+	return func(f *folder) {
+		f.nfc = true
+	}
+}
+
+// FoldSpecialCase lowers runes with the given [unicode.SpecialCase] instead of
+// [unicode.ToLower], for both hashing and equality.
+//
+// The name identifies the mapping in [CaseInsensitiveMap.Folding] so maps using
+// different special cases are never mixed.
+//
+//	m.SetFolding(cimap.FoldSpecialCase("lithuanian", myLithuanianCase))
+func FoldSpecialCase(name string, sc unicode.SpecialCase) FoldOption {
+	return func(f *folder) {
+		f.special = sc
+		f.specialName = name
+	}
+}
+
+// FoldTurkish applies the Turkish and Azeri casing rules, where "I" folds to
+// "ı" and "İ" folds to "i".
+//
+//	m := cimap.New[int]()
+//	m.SetFolding(cimap.FoldTurkish())
+//	m.Add("İstanbul", 34)
+//	m.Get("istanbul") // Output: 34 true
+//	m.Get("Istanbul") // Output: 0 false
+func FoldTurkish() FoldOption {
+	return FoldSpecialCase("turkish", unicode.TurkishCase)
+}
+
+// FoldSeparators ignores the given separators when comparing keys.
+//
+// Separator runes are dropped wherever they appear, so with [SeparatorAll] the
+// keys "CONTENT_TYPE", "content-type", "Content.Type", "contentType" and
+// "contenttype" are all the same key. Keys still report the spelling they were
+// added with.
+//
+//	m := cimap.New[string]()
+//	m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+//	m.Add("Content-Type", "text/plain")
+//	m.Get("contentType") // Output: text/plain true
+func FoldSeparators(seps Separators) FoldOption {
+	return func(f *folder) {
+		f.separators = seps
+	}
+}
+
+// FoldWidth maps fullwidth and halfwidth forms to their canonical width
+// before folding, so "ＡＢＣ" typed on a CJK keyboard matches "abc" and
+// halfwidth katakana match their fullwidth counterparts. Halfwidth voiced and
+// semi-voiced sound marks are joined with the preceding kana, so "ｶﾞ" matches "ガ".
+//
+//	m := cimap.New[int]()
+//	m.SetFolding(cimap.FoldWidth())
+//	m.Add("ＡＢＣ", 1)
+//	m.Get("abc") // Output: 1 true
+func FoldWidth() FoldOption {
+	return func(f *folder) {
+		f.width = true
+	}
+}
+
+// SetFolding replaces the folding options used to hash and compare keys.
+//
+// Calling it without options restores the default case folding. The map is
+// rehashed immediately; keys that become equal under the new folding are merged.
+//
+//	m := cimap.New[string]()
+//	m.SetFolding(cimap.FoldNFC())
+func (c *CaseInsensitiveMap[T]) SetFolding(opts ...FoldOption) {
+	c.folding = newFolder(opts)
+	c.hashString, c.equalFold = c.folding.funcs()
+	c.customHash = false
+	c.rehash()
+}
+
+// Folding returns the name of the active folding mode.
+//
+//	m := cimap.New[string]()
+//	m.Folding() // Output: default
+func (c *CaseInsensitiveMap[T]) Folding() string {
+	return c.folding.name()
+}
+
+// foldingByName returns the options that recreate a built-in folding mode from
+// the name reported by [CaseInsensitiveMap.Folding].
+//
+// Modes using custom special cases or normalizers cannot be recreated by name.
+func foldingByName(name string) ([]FoldOption, error) {
+	if name == DefaultFolding {
+		return nil, nil
+	}
+
+	var opts []FoldOption
+	for _, part := range strings.Split(name, "+") {
+		switch part {
+		case "nfc":
+			opts = append(opts, FoldNFC())
+		case "turkish":
+			opts = append(opts, FoldTurkish())
+		case "width":
+			opts = append(opts, FoldWidth())
+		default:
+			list, ok := strings.CutPrefix(part, "separators:")
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown folding %q", ErrFoldingMismatch, part)
+			}
+			var seps Separators
+			for _, sep := range strings.Split(list, ",") {
+				i := slices.IndexFunc(separatorNames, func(sn separatorName) bool {
+					return sn.name == sep
+				})
+				if i < 0 {
+					return nil, fmt.Errorf("%w: unknown separator %q", ErrFoldingMismatch, sep)
+				}
+				seps |= separatorNames[i].sep
+			}
+			opts = append(opts, FoldSeparators(seps))
+		}
+	}
+	return opts, nil
+}
+
+// matchFolding makes sure the map uses the folding mode called name.
+//
+// A map still using the default folding adopts any built-in mode; otherwise the
+// names must match exactly. A map with a custom hasher never adopts a mode, as
+// that would silently drop the hasher.
+func (c *CaseInsensitiveMap[T]) matchFolding(name string) error {
+	if c.Folding() == name {
+		return nil
+	}
+	if c.customHash {
+		return fmt.Errorf("%w: map uses a custom hasher, data uses %q", ErrFoldingMismatch, name)
+	}
+	if c.folding != nil {
+		return fmt.Errorf("%w: map uses %q, data uses %q", ErrFoldingMismatch, c.Folding(), name)
+	}
+	opts, err := foldingByName(name)
+	if err != nil {
+		return err
+	}
+	c.SetFolding(opts...)
+	return nil
+}
+
+// newWithFolding creates an empty map that hashes and compares keys like src.
+func newWithFolding[U, T any](src *CaseInsensitiveMap[T]) *CaseInsensitiveMap[U] {
+	m := New[U]()
+	if src.hashString != nil {
+		m.folding = src.folding
+		m.hashString = src.hashString
+		m.equalFold = src.equalFold
+		m.customHash = src.customHash
+	}
+	return m
+}
+
+////////////////////////////////////////////////////////////
+// FOLDER METHODS
+////////////////////////////////////////////////////////////
+
+// newFolder applies opts to a new folder, returning nil when opts is empty.
+func newFolder(opts []FoldOption) *folder {
+	if len(opts) == 0 {
+		return nil
+	}
+	f := &folder{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// funcs returns the hash and equality functions of f.
+// A nil folder returns the default case folding functions.
+func (f *folder) funcs() (func(string) hash64, func(string, string) bool) {
+	if f == nil {
+		return defaultHashString, strings.EqualFold
+	}
+	return f.hash, f.equal
+}
+
+// name describes the folder in a stable, human readable form.
+// A nil folder is the default folding.
+func (f *folder) name() string {
+	if f == nil {
+		return DefaultFolding
+	}
+	var parts []string
+	if f.normalizer != nil {
+		parts = append(parts, f.normalizerName)
+	}
+	if f.nfc {
+		parts = append(parts, "nfc")
+	}
+	if f.special != nil {
+		parts = append(parts, f.specialName)
+	}
+	if f.width {
+		parts = append(parts, "width")
+	}
+	if f.separators != 0 {
+		var seps []string
+		for _, sn := range separatorNames {
+			if f.separators&sn.sep != 0 {
+				seps = append(seps, sn.name)
+			}
+		}
+		parts = append(parts, "separators:"+strings.Join(seps, ","))
+	}
+	if len(parts) == 0 {
+		return DefaultFolding
+	}
+	return strings.Join(parts, "+")
+}
+
+// normalize returns key in the form expected by the rune pipeline.
+//
+// Keys that pass the NFC quick check are returned untouched.
+func (f *folder) normalize(key string) string {
+	if f.normalizer != nil {
+		key = f.normalizer.NormalizeKey(key)
+	}
+	if f.nfc && norm.NFC.QuickSpanString(key) != len(key) {
+		return norm.NFC.String(key)
+	}
+	return key
+}
+
+// foldRune maps a single rune to its folded form.
+func (f *folder) foldRune(r rune) rune {
+	if f.special != nil {
+		return f.special.ToLower(r)
+	}
+	return unicode.ToLower(r)
+}
+
+// widthRune maps fullwidth and halfwidth runes to their canonical width.
+func (f *folder) widthRune(r rune) rune {
+	if !f.width || r < utf8.RuneSelf {
+		return r
+	}
+	if folded := width.LookupRune(r).Folded(); folded != 0 {
+		return folded
+	}
+	return r
+}
+
+// soundMarkKana maps a kana to its voiced and semi-voiced forms, or 0 when a
+// form does not exist. Width folding turns the halfwidth sound marks into
+// combining marks, which precomposed kana such as "ガ" do not contain, so
+// foldIter joins them with the preceding kana instead.
+var soundMarkKana = map[rune][2]rune{
+	'ウ': {'ヴ', 0},
+	'カ': {'ガ', 0}, 'キ': {'ギ', 0}, 'ク': {'グ', 0}, 'ケ': {'ゲ', 0}, 'コ': {'ゴ', 0},
+	'サ': {'ザ', 0}, 'シ': {'ジ', 0}, 'ス': {'ズ', 0}, 'セ': {'ゼ', 0}, 'ソ': {'ゾ', 0},
+	'タ': {'ダ', 0}, 'チ': {'ヂ', 0}, 'ツ': {'ヅ', 0}, 'テ': {'デ', 0}, 'ト': {'ド', 0},
+	'ハ': {'バ', 'パ'}, 'ヒ': {'ビ', 'ピ'}, 'フ': {'ブ', 'プ'}, 'ヘ': {'ベ', 'ペ'}, 'ホ': {'ボ', 'ポ'},
+	'ワ': {'ヷ', 0}, 'ヲ': {'ヺ', 0},
+}
+
+// composeSoundMark joins r with a halfwidth sound mark at the start of rest.
+// It returns the composed rune and the size of the mark, or r and 0.
+func composeSoundMark(r rune, rest string) (rune, int) {
+	mark, size := utf8.DecodeRuneInString(rest)
+	if mark != '\uFF9E' && mark != '\uFF9F' {
+		return r, 0
+	}
+	forms, ok := soundMarkKana[r]
+	if !ok {
+		return r, 0
+	}
+	if composed := forms[mark-'\uFF9E']; composed != 0 {
+		return composed, size
+	}
+	return r, 0
+}
+
+// isSeparator reports whether r is one of the enabled separator runes.
+func (f *folder) isSeparator(r rune) bool {
+	switch r {
+	case '-':
+		return f.separators&SeparatorHyphen != 0
+	case '_':
+		return f.separators&SeparatorUnderscore != 0
+	case '.':
+		return f.separators&SeparatorDot != 0
+	}
+	return f.separators&SeparatorSpace != 0 && unicode.IsSpace(r)
+}
+
+// hash computes the same FNV variant as defaultHashString over the folded runes of key.
+func (f *folder) hash(key string) hash64 {
+	h := offset64
+	it := foldIter{f: f, s: f.normalize(key)}
+	for r, ok := it.next(); ok; r, ok = it.next() {
+		h *= prime64
+		h ^= uint64(r)
+	}
+	return h
+}
+
+// equal reports whether a and b fold to the same sequence of runes.
+func (f *folder) equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ia := foldIter{f: f, s: f.normalize(a)}
+	ib := foldIter{f: f, s: f.normalize(b)}
+	for {
+		ra, okA := ia.next()
+		rb, okB := ib.next()
+		if okA != okB || ra != rb {
+			return false
+		}
+		if !okA {
+			return true
+		}
+	}
+}
+
+// next returns the next folded rune, or false once the key is exhausted.
+func (it *foldIter) next() (rune, bool) {
+	for len(it.s) > 0 {
+		r, size := utf8.DecodeRuneInString(it.s)
+		it.s = it.s[size:]
+		r = it.f.widthRune(r)
+		if it.f.width && r >= 'ウ' && r <= 'ヲ' {
+			var n int
+			r, n = composeSoundMark(r, it.s)
+			it.s = it.s[n:]
+		}
+
+		if it.f.separators != 0 && it.f.isSeparator(r) {
+			continue
+		}
+		return it.f.foldRune(r), true
+	}
+	return 0, false
+}
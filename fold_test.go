@@ -0,0 +1,409 @@
+package cimap_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	precomposed = "caf\u00e9"  // é as a single code point
+	decomposed  = "cafe\u0301" // e followed by a combining acute accent
+)
+
+func TestFoldNFC(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   string
+		lookup   string
+		expected bool
+	}{
+		{
+			name:     "Precomposed insert, decomposed lookup",
+			insert:   precomposed,
+			lookup:   decomposed,
+			expected: true,
+		},
+		{
+			name:     "Decomposed insert, precomposed lookup",
+			insert:   decomposed,
+			lookup:   precomposed,
+			expected: true,
+		},
+		{
+			name:     "Decomposed insert, uppercase precomposed lookup",
+			insert:   decomposed,
+			lookup:   "CAF\u00c9",
+			expected: true,
+		},
+		{
+			name:     "Uppercase decomposed insert, precomposed lookup",
+			insert:   "CAFE\u0301",
+			lookup:   precomposed,
+			expected: true,
+		},
+		{
+			name:     "Different accents stay distinct",
+			insert:   precomposed,
+			lookup:   "cafè",
+			expected: false,
+		},
+		{
+			name:     "Plain ASCII fast path",
+			insert:   "Hello",
+			lookup:   "hELLO",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[string]()
+			m.SetFolding(cimap.FoldNFC())
+			m.Add(tt.insert, "value")
+
+			_, ok := m.Get(tt.lookup)
+			assert.Equal(t, tt.expected, ok, "Unexpected existence for key %q", tt.lookup)
+		})
+	}
+}
+
+func TestFoldNFC_DefaultDistinguishesForms(t *testing.T) {
+	m := cimap.New[int]()
+	m.Add(precomposed, 1)
+	m.Add(decomposed, 2)
+	assert.Equal(t, 2, m.Len(), "Default folding should not apply canonical equivalence")
+}
+
+func TestSetFolding(t *testing.T) {
+	m := cimap.New[int]()
+	m.Add(precomposed, 1)
+	m.Add(decomposed, 2)
+	m.Add("Other", 3)
+	assert.Equal(t, cimap.DefaultFolding, m.Folding())
+
+	m.SetFolding(cimap.FoldNFC())
+	assert.Equal(t, "nfc", m.Folding())
+	assert.Equal(t, 2, m.Len(), "Equivalent keys should merge when folding changes")
+	_, ok := m.Get("OTHER")
+	assert.True(t, ok, "Unrelated keys should survive the rehash")
+
+	m.Delete(decomposed)
+	assert.Equal(t, 1, m.Len())
+	_, ok = m.Get(precomposed)
+	assert.False(t, ok)
+
+	m.SetFolding()
+	assert.Equal(t, cimap.DefaultFolding, m.Folding())
+	_, ok = m.Get("other")
+	assert.True(t, ok)
+}
+
+func TestFoldTurkish(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   string
+		lookup   string
+		expected bool
+	}{
+		{
+			name:     "Dotted capital I matches dotted i",
+			insert:   "İstanbul",
+			lookup:   "istanbul",
+			expected: true,
+		},
+		{
+			name:     "Dotless capital I matches dotless i",
+			insert:   "ISPARTA",
+			lookup:   "ısparta",
+			expected: true,
+		},
+		{
+			name:     "Dotless capital I does not match dotted i",
+			insert:   "ISPARTA",
+			lookup:   "isparta",
+			expected: false,
+		},
+		{
+			name:     "Dotted capital I does not match dotless i",
+			insert:   "İstanbul",
+			lookup:   "ıstanbul",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[string]()
+			m.SetFolding(cimap.FoldTurkish())
+			m.Add(tt.insert, "value")
+
+			_, ok := m.Get(tt.lookup)
+			assert.Equal(t, tt.expected, ok, "Unexpected existence for key %q", tt.lookup)
+
+			m.Delete(tt.lookup)
+			assert.Equal(t, !tt.expected, m.Len() == 1, "Delete should agree with Get for key %q", tt.lookup)
+		})
+	}
+}
+
+func TestFoldSpecialCase(t *testing.T) {
+	m := cimap.New[int]()
+	m.SetFolding(cimap.FoldNFC(), cimap.FoldSpecialCase("azeri", unicode.AzeriCase))
+	assert.Equal(t, "nfc+azeri", m.Folding())
+
+	m.Add("I\u0307", 1) // I followed by a combining dot above composes to U+0130
+	val, ok := m.Get("i")
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+}
+
+func TestFoldSeparators(t *testing.T) {
+	tests := []struct {
+		name     string
+		seps     cimap.Separators
+		insert   string
+		lookup   string
+		expected bool
+	}{
+		{
+			name:     "Env var matches header",
+			seps:     cimap.SeparatorAll,
+			insert:   "CONTENT_TYPE",
+			lookup:   "content-type",
+			expected: true,
+		},
+		{
+			name:     "Header matches camelCase",
+			seps:     cimap.SeparatorAll,
+			insert:   "content-type",
+			lookup:   "contentType",
+			expected: true,
+		},
+		{
+			name:     "Dot and whitespace",
+			seps:     cimap.SeparatorAll,
+			insert:   "content.type",
+			lookup:   "Content \t Type",
+			expected: true,
+		},
+		{
+			name:     "Acronym followed by word",
+			seps:     cimap.SeparatorAll,
+			insert:   "HTTPServer",
+			lookup:   "http_server",
+			expected: true,
+		},
+		{
+			name:     "Runs and edges are ignored",
+			seps:     cimap.SeparatorAll,
+			insert:   "__content--type__",
+			lookup:   "content_type",
+			expected: true,
+		},
+		{
+			name:     "Separators are dropped",
+			seps:     cimap.SeparatorAll,
+			insert:   "content_type",
+			lookup:   "contenttype",
+			expected: true,
+		},
+		{
+			name:     "CamelCase matches all caps",
+			seps:     cimap.SeparatorAll,
+			insert:   "contentType",
+			lookup:   "CONTENTTYPE",
+			expected: true,
+		},
+		{
+			name:     "CamelCase matches all lower case",
+			seps:     cimap.SeparatorAll,
+			insert:   "contentType",
+			lookup:   "contenttype",
+			expected: true,
+		},
+		{
+			name:     "Acronym matches all lower case",
+			seps:     cimap.SeparatorAll,
+			insert:   "HTTPServer",
+			lookup:   "httpserver",
+			expected: true,
+		},
+		{
+			name:     "Single separator is dropped",
+			seps:     cimap.SeparatorHyphen,
+			insert:   "content-type",
+			lookup:   "contenttype",
+			expected: true,
+		},
+		{
+			name:     "Other letters still differ",
+			seps:     cimap.SeparatorAll,
+			insert:   "content_type",
+			lookup:   "contents_type",
+			expected: false,
+		},
+		{
+			name:     "Disabled separator is a literal",
+			seps:     cimap.SeparatorHyphen,
+			insert:   "content-type",
+			lookup:   "content_type",
+			expected: false,
+		},
+		{
+			name:     "Camel case needs no flag",
+			seps:     cimap.SeparatorUnderscore,
+			insert:   "content_type",
+			lookup:   "contentType",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[string]()
+			m.SetFolding(cimap.FoldSeparators(tt.seps))
+			m.Add(tt.insert, "value")
+
+			_, ok := m.Get(tt.lookup)
+			assert.Equal(t, tt.expected, ok, "Unexpected existence for key %q", tt.lookup)
+		})
+	}
+}
+
+func TestFoldSeparators_KeysKeepSpelling(t *testing.T) {
+	m := cimap.New[int]()
+	m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+	m.Add("CONTENT_TYPE", 1)
+	m.Add("contentLength", 2)
+	m.Add("content-length", 3)
+	assert.Equal(t, "separators:hyphen,underscore,dot,space,camel", m.Folding())
+
+	var keys []string
+	for k := range m.Keys() {
+		keys = append(keys, k)
+	}
+	assert.ElementsMatch(t, []string{"CONTENT_TYPE", "content-length"}, keys)
+}
+
+func TestFoldWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []cimap.FoldOption
+		insert   string
+		lookup   string
+		expected bool
+	}{
+		{
+			name:     "Fullwidth Latin matches ASCII",
+			opts:     []cimap.FoldOption{cimap.FoldWidth()},
+			insert:   "ＡＢＣ",
+			lookup:   "abc",
+			expected: true,
+		},
+		{
+			name:     "Fullwidth digits match ASCII",
+			opts:     []cimap.FoldOption{cimap.FoldWidth()},
+			insert:   "ｋｅｙ１２",
+			lookup:   "KEY12",
+			expected: true,
+		},
+		{
+			name:     "Halfwidth katakana matches fullwidth",
+			opts:     []cimap.FoldOption{cimap.FoldWidth()},
+			insert:   "ｶﾀｶﾅ",
+			lookup:   "カタカナ",
+			expected: true,
+		},
+		{
+			name:     "Halfwidth voiced katakana matches precomposed",
+			opts:     []cimap.FoldOption{cimap.FoldWidth()},
+			insert:   "\u30ac\u30d1",             // ガパ
+			lookup:   "\uff76\uff9e\uff8a\uff9f", // ｶﾞﾊﾟ
+			expected: true,
+		},
+		{
+			name:     "Halfwidth voiced katakana with NFC",
+			opts:     []cimap.FoldOption{cimap.FoldWidth(), cimap.FoldNFC()},
+			insert:   "\u30ac",       // ガ
+			lookup:   "\uff76\uff9e", // ｶﾞ
+			expected: true,
+		},
+		{
+			name:     "Halfwidth sound mark without kana",
+			opts:     []cimap.FoldOption{cimap.FoldWidth()},
+			insert:   "\uff9e",
+			lookup:   "\u3099",
+			expected: true,
+		},
+		{
+			name:     "Fullwidth separators",
+			opts:     []cimap.FoldOption{cimap.FoldWidth(), cimap.FoldSeparators(cimap.SeparatorAll)},
+			insert:   "ｃｏｎｔｅｎｔＴｙｐｅ",
+			lookup:   "content＿type",
+			expected: true,
+		},
+		{
+			name:     "Width folding disabled",
+			insert:   "ＡＢＣ",
+			lookup:   "abc",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[string]()
+			m.SetFolding(tt.opts...)
+			m.Add(tt.insert, "value")
+
+			_, ok := m.Get(tt.lookup)
+			assert.Equal(t, tt.expected, ok, "Unexpected existence for key %q", tt.lookup)
+		})
+	}
+}
+
+func TestFoldWidth_NoAllocs(t *testing.T) {
+	m := cimap.New[int]()
+	m.SetFolding(cimap.FoldWidth())
+	m.Add("ＡＢＣ", 1)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Get("ａｂｃ")
+	})
+	assert.Zero(t, allocs, "Expected width folding to avoid allocations")
+
+	m.Add("\u30ac\u30ae\u30b0", 2) // ガギグ
+	allocs = testing.AllocsPerRun(100, func() {
+		if _, ok := m.Get("\uff76\uff9e\uff77\uff9e\uff78\uff9e"); !ok { // ｶﾞｷﾞｸﾞ
+			t.Fatal("Expected halfwidth voiced katakana to match")
+		}
+	})
+	assert.Zero(t, allocs, "Expected sound mark composition to avoid allocations")
+}
+
+func TestFoldNormalizer(t *testing.T) {
+	trim := cimap.KeyNormalizerFunc(func(k string) string {
+		return strings.TrimPrefix(strings.TrimSpace(k), "X-")
+	})
+
+	m := cimap.New[string]()
+	m.SetFolding(cimap.FoldNormalizer("trim-x", trim), cimap.FoldSeparators(cimap.SeparatorHyphen))
+	assert.Equal(t, "trim-x+separators:hyphen", m.Folding())
+
+	m.Add("X-Request-Id", "abc")
+	val, ok := m.Get("  request--ID ")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", val)
+
+	m.Delete("request-id")
+	assert.Equal(t, 0, m.Len())
+}
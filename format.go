@@ -0,0 +1,154 @@
+package cimap
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Stats describes how keys are spread over the internal hash buckets.
+type Stats struct {
+	Len          int // number of keys
+	Buckets      int // number of distinct hashes in use
+	Collisions   int // keys sharing their hash with another key
+	LongestChain int // most keys stored under a single hash
+}
+
+// Stats returns the current bucket statistics of the map.
+//
+// A high collision count with the default folding usually points to a poor
+// custom hasher.
+//
+//	m := cimap.New[int]()
+//	m.Add("a", 1)
+//	m.Stats() // Output: {Len:1 Buckets:1 Collisions:0 LongestChain:1}
+func (c *CaseInsensitiveMap[T]) Stats() Stats {
+	s := Stats{Len: c.size, Buckets: len(c.internalMap)}
+	for _, v := range c.internalMap {
+		chain := 0
+		for ; v != nil; v = v.Next {
+			chain++
+		}
+		if chain > 1 {
+			s.Collisions += chain
+		}
+		s.LongestChain = max(s.LongestChain, chain)
+	}
+	return s
+}
+
+// String implements the [fmt.Stringer] interface.
+//
+// It prints the map like a Go map literal with keys in [OrderSorted].
+//
+//	m := cimap.New[int]()
+//	m.Add("b", 2)
+//	m.Add("A", 1)
+//	m.String() // Output: {A:1 b:2}
+func (c *CaseInsensitiveMap[T]) String() string {
+	if c == nil {
+		return "<nil>"
+	}
+	var b strings.Builder
+	c.writeEntries(&b, "%v")
+	return b.String()
+}
+
+// GoString implements the [fmt.GoStringer] interface.
+//
+// It returns a Go expression that rebuilds the map with the same keys, values,
+// insertion order and built-in folding mode.
+//
+//	fmt.Printf("%#v", m) // Output: func() *cimap.CaseInsensitiveMap[int] { m := cimap.New[int](1); m.Add("A", 1); return m }()
+func (c *CaseInsensitiveMap[T]) GoString() string {
+	typ := reflect.TypeFor[T]().String()
+	if c == nil {
+		return fmt.Sprintf("(*cimap.CaseInsensitiveMap[%s])(nil)", typ)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func() *cimap.CaseInsensitiveMap[%s] { m := cimap.New[%s](%d); ", typ, typ, c.size)
+	if opts := foldingGoString(c.Folding()); opts != "" {
+		fmt.Fprintf(&b, "m.SetFolding(%s); ", opts)
+	}
+	for _, n := range c.nodes(OrderInsertion) {
+		fmt.Fprintf(&b, "m.Add(%q, %#v); ", n.Key, n.Value)
+	}
+	b.WriteString("return m }()")
+	return b.String()
+}
+
+// Format implements the [fmt.Formatter] interface.
+//
+// %v and %s print the same as [CaseInsensitiveMap.String], %+v also prints
+// values with %+v followed by the [Stats] and folding mode, and %#v prints
+// [CaseInsensitiveMap.GoString].
+//
+//	fmt.Printf("%+v", m) // Output: {A:1 b:2} (len=2 buckets=2 collisions=0 longest=1 folding=default)
+func (c *CaseInsensitiveMap[T]) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, c.GoString())
+	case verb == 'v' && f.Flag('+'):
+		if c == nil {
+			fmt.Fprint(f, "<nil>")
+			return
+		}
+		c.writeEntries(f, "%+v")
+		s := c.Stats()
+		fmt.Fprintf(f, " (len=%d buckets=%d collisions=%d longest=%d folding=%s)",
+			s.Len, s.Buckets, s.Collisions, s.LongestChain, c.Folding())
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(f, c.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*cimap.CaseInsensitiveMap=%s)", verb, c.String())
+	}
+}
+
+// writeEntries writes {key:value ...} in sorted order, formatting values with valueFormat.
+func (c *CaseInsensitiveMap[T]) writeEntries(w io.Writer, valueFormat string) {
+	fmt.Fprint(w, "{")
+	for i, n := range c.nodes(OrderSorted) {
+		if i > 0 {
+			fmt.Fprint(w, " ")
+		}
+		fmt.Fprintf(w, "%s:"+valueFormat, n.Key, n.Value)
+	}
+	fmt.Fprint(w, "}")
+}
+
+// foldingGoString returns the FoldOption expressions for a built-in folding
+// mode name. Custom special cases and normalizers cannot be expressed and are left out.
+func foldingGoString(name string) string {
+	if name == DefaultFolding {
+		return ""
+	}
+
+	var opts []string
+	for _, part := range strings.Split(name, "+") {
+		switch part {
+		case "nfc":
+			opts = append(opts, "cimap.FoldNFC()")
+		case "turkish":
+			opts = append(opts, "cimap.FoldTurkish()")
+		case "width":
+			opts = append(opts, "cimap.FoldWidth()")
+		default:
+			list, ok := strings.CutPrefix(part, "separators:")
+			if !ok {
+				continue
+			}
+			var seps []string
+			for _, sep := range strings.Split(list, ",") {
+				for _, sn := range separatorNames {
+					if sn.name == sep {
+						seps = append(seps, "cimap."+sn.goName)
+					}
+				}
+			}
+			opts = append(opts, "cimap.FoldSeparators("+strings.Join(seps, "|")+")")
+		}
+	}
+	return strings.Join(opts, ", ")
+}
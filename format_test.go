@@ -0,0 +1,127 @@
+package cimap_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	m := cimap.New[int]()
+	m.Add("b", 2)
+	m.Add("A", 1)
+	m.Add("C", 3)
+
+	tests := []struct {
+		name     string
+		format   string
+		value    any
+		expected string
+	}{
+		{
+			name:     "Value",
+			format:   "%v",
+			value:    m,
+			expected: "{A:1 b:2 C:3}",
+		},
+		{
+			name:     "String",
+			format:   "%s",
+			value:    m,
+			expected: "{A:1 b:2 C:3}",
+		},
+		{
+			name:     "Plus",
+			format:   "%+v",
+			value:    m,
+			expected: "{A:1 b:2 C:3} (len=3 buckets=3 collisions=0 longest=1 folding=default)",
+		},
+		{
+			name:     "Go syntax",
+			format:   "%#v",
+			value:    m,
+			expected: `func() *cimap.CaseInsensitiveMap[int] { m := cimap.New[int](3); m.Add("b", 2); m.Add("A", 1); m.Add("C", 3); return m }()`,
+		},
+		{
+			name:     "Struct values",
+			format:   "%+v",
+			value:    newPointMap(point{1, 2}),
+			expected: "{P:{X:1 Y:2}} (len=1 buckets=1 collisions=0 longest=1 folding=default)",
+		},
+		{
+			name:     "Nested maps",
+			format:   "%v",
+			value:    newNestedMap(),
+			expected: "{Outer:{Inner:1}}",
+		},
+		{
+			name:     "Nil map",
+			format:   "%v",
+			value:    (*cimap.CaseInsensitiveMap[int])(nil),
+			expected: "<nil>",
+		},
+		{
+			name:     "Nil map Go syntax",
+			format:   "%#v",
+			value:    (*cimap.CaseInsensitiveMap[int])(nil),
+			expected: "(*cimap.CaseInsensitiveMap[int])(nil)",
+		},
+		{
+			name:     "Bad verb",
+			format:   "%d",
+			value:    m,
+			expected: "%!d(*cimap.CaseInsensitiveMap={A:1 b:2 C:3})",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fmt.Sprintf(tt.format, tt.value))
+		})
+	}
+}
+
+func TestGoString_Folding(t *testing.T) {
+	m := cimap.New[string]()
+	m.SetFolding(cimap.FoldNFC(), cimap.FoldSeparators(cimap.SeparatorHyphen|cimap.SeparatorCamelCase))
+	m.Add("Content-Type", "text/plain")
+
+	assert.Equal(t,
+		`func() *cimap.CaseInsensitiveMap[string] { m := cimap.New[string](1); `+
+			`m.SetFolding(cimap.FoldNFC(), cimap.FoldSeparators(cimap.SeparatorHyphen|cimap.SeparatorCamelCase)); `+
+			`m.Add("Content-Type", "text/plain"); return m }()`,
+		m.GoString())
+}
+
+func TestStats(t *testing.T) {
+	m := cimap.New[int]()
+	m.SetHasher(func(s string) uint64 { return uint64(len(s)) })
+	m.Add("a", 1)
+	m.Add("bb", 2)
+	m.Add("cc", 3)
+	m.Add("dd", 4)
+
+	assert.Equal(t, cimap.Stats{Len: 4, Buckets: 2, Collisions: 3, LongestChain: 3}, m.Stats())
+}
+
+func newPointMap[T any](p T) *cimap.CaseInsensitiveMap[T] {
+	m := cimap.New[T]()
+	m.Add("P", p)
+	return m
+}
+
+func newNestedMap() *cimap.CaseInsensitiveMap[any] {
+	inner := cimap.New[any]()
+	inner.Add("Inner", 1)
+	outer := cimap.New[any]()
+	outer.Add("Outer", inner)
+	return outer
+}
@@ -0,0 +1,80 @@
+package cimap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// gobVersion is the first byte of every gob encoded map.
+// Bump it whenever gobPayload changes shape.
+const gobVersion byte = 1
+
+// gobPayload is the version 1 gob representation of a map.
+type gobPayload[T any] struct {
+	Folding string
+	Keys    []string
+	Values  []T
+}
+
+// GobEncode implements the [gob.GobEncoder] interface.
+//
+// The original casing of keys, their insertion order and the name of the
+// active folding mode are preserved. Maps with custom hashers are encoded like
+// any other map; the hasher has to be installed again after decoding.
+//
+//	var buf bytes.Buffer
+//	err := gob.NewEncoder(&buf).Encode(m)
+func (c *CaseInsensitiveMap[T]) GobEncode() ([]byte, error) {
+	p := gobPayload[T]{
+		Folding: c.Folding(),
+		Keys:    make([]string, 0, c.size),
+		Values:  make([]T, 0, c.size),
+	}
+	for _, n := range c.nodes(OrderInsertion) {
+		p.Keys = append(p.Keys, n.Key)
+		p.Values = append(p.Values, n.Value)
+	}
+
+	buf := bytes.NewBuffer([]byte{gobVersion})
+	if err := gob.NewEncoder(buf).Encode(p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements the [gob.GobDecoder] interface.
+//
+// Any existing data in the map is cleared. A map using the default folding
+// and no custom hasher adopts the built-in folding mode recorded in the data;
+// otherwise the modes must match or an error wrapping [ErrFoldingMismatch] is
+// returned.
+//
+//	m := cimap.New[int]()
+//	err := gob.NewDecoder(&buf).Decode(m)
+func (c *CaseInsensitiveMap[T]) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("cimap: empty gob data")
+	}
+	if data[0] != gobVersion {
+		return fmt.Errorf("cimap: unsupported gob version %d", data[0])
+	}
+
+	var p gobPayload[T]
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&p); err != nil {
+		return err
+	}
+	if len(p.Keys) != len(p.Values) {
+		return fmt.Errorf("cimap: gob data has %d keys but %d values", len(p.Keys), len(p.Values))
+	}
+
+	c.reset(len(p.Keys))
+	if err := c.matchFolding(p.Folding); err != nil {
+		return err
+	}
+	for i, k := range p.Keys {
+		c.Add(k, p.Values[i])
+	}
+	return nil
+}
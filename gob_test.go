@@ -0,0 +1,114 @@
+package cimap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGobEncodeDecode(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.Add("Zeta", 1)
+		m.Add("alpha", 2)
+		m.Add("Mid", 3)
+
+		var buf bytes.Buffer
+		assert.NoError(t, gob.NewEncoder(&buf).Encode(m))
+
+		var m2 cimap.CaseInsensitiveMap[int]
+		assert.NoError(t, gob.NewDecoder(&buf).Decode(&m2))
+		assert.Equal(t, 3, m2.Len())
+		val, ok := m2.Get("ALPHA")
+		assert.True(t, ok)
+		assert.Equal(t, 2, val)
+
+		var keys []string
+		for k := range m2.Keys() {
+			keys = append(keys, k)
+		}
+		assert.ElementsMatch(t, []string{"Zeta", "alpha", "Mid"}, keys, "Expected original casing")
+	})
+
+	t.Run("Struct field", func(t *testing.T) {
+		type cache struct {
+			Aliases *cimap.CaseInsensitiveMap[string]
+		}
+		in := cache{Aliases: cimap.New[string]()}
+		in.Aliases.Add("Go", "golang")
+
+		var buf bytes.Buffer
+		assert.NoError(t, gob.NewEncoder(&buf).Encode(in))
+
+		var out cache
+		assert.NoError(t, gob.NewDecoder(&buf).Decode(&out))
+		val, ok := out.Aliases.Get("GO")
+		assert.True(t, ok)
+		assert.Equal(t, "golang", val)
+	})
+
+	t.Run("Restores folding", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.SetFolding(cimap.FoldTurkish(), cimap.FoldSeparators(cimap.SeparatorUnderscore|cimap.SeparatorCamelCase))
+		m.Add("İsim_Soyisim", 1)
+
+		data, err := m.GobEncode()
+		assert.NoError(t, err)
+
+		m2 := cimap.New[int]()
+		assert.NoError(t, m2.GobDecode(data))
+		assert.Equal(t, m.Folding(), m2.Folding())
+		_, ok := m2.Get("isimSoyisim")
+		assert.True(t, ok)
+	})
+
+	t.Run("Folding mismatch", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.SetFolding(cimap.FoldNFC())
+		m.Add("a", 1)
+		data, err := m.GobEncode()
+		assert.NoError(t, err)
+
+		m2 := cimap.New[int]()
+		m2.SetFolding(cimap.FoldWidth())
+		assert.ErrorIs(t, m2.GobDecode(data), cimap.ErrFoldingMismatch)
+	})
+
+	t.Run("Custom hasher is kept", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.SetFolding(cimap.FoldNFC())
+		m.Add("a", 1)
+		data, err := m.GobEncode()
+		assert.NoError(t, err)
+
+		calls := 0
+		m2 := cimap.New[int]()
+		m2.SetHasher(func(s string) uint64 { calls++; return uint64(len(s)) })
+		assert.ErrorIs(t, m2.GobDecode(data), cimap.ErrFoldingMismatch)
+		m2.Add("b", 2)
+		assert.NotZero(t, calls, "Expected the custom hasher to stay installed")
+
+		plain, err := cimap.New[int]().GobEncode()
+		assert.NoError(t, err)
+		assert.NoError(t, m2.GobDecode(plain))
+	})
+
+	t.Run("Custom folding cannot be restored", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.SetFolding(cimap.FoldNormalizer("custom", cimap.KeyNormalizerFunc(func(s string) string { return s })))
+		data, err := m.GobEncode()
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, cimap.New[int]().GobDecode(data), cimap.ErrFoldingMismatch)
+	})
+
+	t.Run("Bad version", func(t *testing.T) {
+		m := cimap.New[int]()
+		assert.Error(t, m.GobDecode(nil))
+		assert.Error(t, m.GobDecode([]byte{99}))
+	})
+}
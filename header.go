@@ -0,0 +1,148 @@
+package cimap
+
+import (
+	"iter"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// HeaderView gives case-insensitive read access to an [http.Header] without
+// copying it.
+//
+// The view indexes the header keys by their folding hash when it is created
+// and reads values straight from the header, so changes to existing values are
+// visible through the view. Keys added to the header afterwards are not; create
+// a new view instead.
+type HeaderView struct {
+	header http.Header
+	index  map[hash64][]string
+	hash   func(string) hash64
+	equal  func(string, string) bool
+	size   int
+}
+
+// FromHeader copies h into a new map, keeping every key as it appears in h.
+//
+// Keys of h that only differ in case, which net/http never produces but which
+// can be set directly, are merged by appending their values in sorted key
+// order. The value slices are shared with h.
+//
+//	m := cimap.FromHeader(r.Header)
+//	v, ok := m.Get("x-custom-header")
+func FromHeader(h http.Header) *CaseInsensitiveMap[[]string] {
+	m := New[[]string](len(h))
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		vs := h[k]
+		if n := m.getNode(k); n != nil {
+			n.Value = append(slices.Clip(n.Value), vs...)
+			continue
+		}
+		m.Add(k, vs)
+	}
+	return m
+}
+
+// ToHeader copies m into a new [http.Header].
+//
+// Keys are written with the casing stored in m rather than in canonical form,
+// so use [http.Header.Values] only for keys that were already canonical, or
+// read the result through a [HeaderView]. The value slices are shared with m.
+//
+//	h := cimap.ToHeader(m)
+//	h.Write(w) // X-custom-HEADER: value
+func ToHeader(m *CaseInsensitiveMap[[]string]) http.Header {
+	h := make(http.Header, m.Len())
+	for _, n := range m.nodes(OrderInsertion) {
+		h[n.Key] = n.Value
+	}
+	return h
+}
+
+// NewHeaderView returns a view of h that compares keys using the given folding
+// options, or simple case folding when none are given.
+//
+//	v := cimap.NewHeaderView(r.Header)
+//	v.Get("x-request-id") // Output: "abc123"
+func NewHeaderView(h http.Header, opts ...FoldOption) *HeaderView {
+	v := &HeaderView{header: h, index: make(map[hash64][]string, len(h))}
+	v.hash, v.equal = newFolder(opts).funcs()
+
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		hk := v.hash(k)
+		if !slices.ContainsFunc(v.index[hk], func(other string) bool { return v.equal(other, k) }) {
+			v.size++
+		}
+		v.index[hk] = append(v.index[hk], k)
+	}
+	return v
+}
+
+// keys returns the header keys equal to k, in sorted order.
+func (v *HeaderView) keys(k string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, key := range v.index[v.hash(k)] {
+			if !v.equal(key, k) {
+				continue
+			}
+			if _, ok := v.header[key]; ok && !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Key returns the key as it appears in the header.
+//
+//	v.Key("X-REQUEST-ID") // Output: "x-request-id" true
+func (v *HeaderView) Key(k string) (string, bool) {
+	for key := range v.keys(k) {
+		return key, true
+	}
+	return "", false
+}
+
+// Values returns the values stored for k. The returned slice belongs to the
+// header unless several keys of the header match k, in which case their
+// values are joined in sorted key order.
+//
+//	v.Values("accept") // Output: [text/html application/json]
+func (v *HeaderView) Values(k string) []string {
+	var vals []string
+	n := 0
+	for key := range v.keys(k) {
+		if n == 0 {
+			vals = v.header[key]
+		} else {
+			vals = append(slices.Clip(vals), v.header[key]...)
+		}
+		n++
+	}
+	return vals
+}
+
+// Get returns the first value stored for k, or "" if there is none.
+//
+//	v.Get("content-type") // Output: "text/html"
+func (v *HeaderView) Get(k string) string {
+	if vals := v.Values(k); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// Has reports whether the header holds k.
+func (v *HeaderView) Has(k string) bool {
+	_, ok := v.Key(k)
+	return ok
+}
+
+// Len returns the number of distinct keys in the header when the view was created.
+func (v *HeaderView) Len() int {
+	return v.size
+}
+
+// Header returns the wrapped header.
+func (v *HeaderView) Header() http.Header {
+	return v.header
+}
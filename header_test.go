@@ -0,0 +1,132 @@
+package cimap_test
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   map[string][]string
+	}{
+		{
+			name:   "Canonical keys",
+			header: http.Header{"Content-Type": {"text/html"}, "Accept": {"a", "b"}},
+			want:   map[string][]string{"content-type": {"text/html"}, "ACCEPT": {"a", "b"}},
+		},
+		{
+			name:   "Wire casing",
+			header: http.Header{"x-custom-ID": {"42"}},
+			want:   map[string][]string{"X-Custom-Id": {"42"}},
+		},
+		{
+			name:   "Merged keys",
+			header: http.Header{"X-Foo": {"1"}, "x-foo": {"2", "3"}},
+			want:   map[string][]string{"x-FOO": {"1", "2", "3"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.FromHeader(tt.header)
+			assert.Equal(t, len(tt.want), m.Len())
+			for k, want := range tt.want {
+				got, ok := m.Get(k)
+				assert.True(t, ok, k)
+				assert.Equal(t, want, got, k)
+			}
+		})
+	}
+
+	t.Run("Source unchanged", func(t *testing.T) {
+		vals := make([]string, 1, 4)
+		vals[0] = "1"
+		h := http.Header{"X-Foo": vals, "x-foo": {"2"}}
+		cimap.FromHeader(h)
+		assert.Equal(t, []string{"1"}, h["X-Foo"])
+		assert.Equal(t, "1", vals[:2][0])
+		assert.Equal(t, "", vals[:2][1])
+	})
+}
+
+func TestToHeader(t *testing.T) {
+	m := cimap.New[[]string]()
+	m.Add("x-custom-ID", []string{"42"})
+	m.Add("Content-Type", []string{"text/plain"})
+
+	h := cimap.ToHeader(m)
+	assert.Equal(t, http.Header{"x-custom-ID": {"42"}, "Content-Type": {"text/plain"}}, h)
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.Write(&buf))
+	assert.Equal(t, "Content-Type: text/plain\r\nx-custom-ID: 42\r\n", buf.String())
+
+	back := cimap.FromHeader(h)
+	assert.Equal(t, slices.Sorted(m.Keys()), slices.Sorted(back.Keys()))
+}
+
+func TestHeaderView(t *testing.T) {
+	h := http.Header{
+		"Content-Type": {"text/html"},
+		"x-request-id": {"abc"},
+		"Accept":       {"text/html"},
+		"ACCEPT":       {"application/json"},
+	}
+	v := cimap.NewHeaderView(h)
+
+	tests := []struct {
+		name   string
+		key    string
+		wire   string
+		values []string
+	}{
+		{name: "Canonical", key: "content-type", wire: "Content-Type", values: []string{"text/html"}},
+		{name: "Wire casing", key: "X-Request-Id", wire: "x-request-id", values: []string{"abc"}},
+		{name: "Several keys", key: "accept", wire: "ACCEPT", values: []string{"application/json", "text/html"}},
+		{name: "Missing", key: "Cookie"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			wire, ok := v.Key(tt.key)
+			assert.Equal(t, tt.wire, wire)
+			assert.Equal(t, tt.wire != "", ok)
+			assert.Equal(t, tt.wire != "", v.Has(tt.key))
+			assert.Equal(t, tt.values, v.Values(tt.key))
+			if len(tt.values) > 0 {
+				assert.Equal(t, tt.values[0], v.Get(tt.key))
+			} else {
+				assert.Equal(t, "", v.Get(tt.key))
+			}
+		})
+	}
+
+	t.Run("Len", func(t *testing.T) {
+		assert.Equal(t, 3, v.Len())
+	})
+
+	t.Run("Read through", func(t *testing.T) {
+		h := http.Header{"X-Token": {"old"}}
+		v := cimap.NewHeaderView(h)
+		h.Set("X-Token", "new")
+		assert.Equal(t, "new", v.Get("x-token"))
+		h.Del("X-Token")
+		assert.False(t, v.Has("x-token"))
+		assert.Equal(t, h, v.Header())
+	})
+
+	t.Run("Folding", func(t *testing.T) {
+		v := cimap.NewHeaderView(http.Header{"X-Request-Id": {"abc"}}, cimap.FoldSeparators(cimap.SeparatorHyphen|cimap.SeparatorUnderscore))
+		assert.Equal(t, "abc", v.Get("x_request_id"))
+	})
+}
@@ -0,0 +1,215 @@
+package cimap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ReadINI parses an INI or .properties file into a map of sections, each
+// holding the keys of that section. Section and key names are compared
+// case-insensitively and keep the casing they first appear with; sections and
+// keys keep the order of the file.
+//
+// Keys before the first [section] header belong to the section named "".
+// Lines starting with ';', '#' or '!' are comments. Keys are separated from
+// their values by '=' or ':'. A ';' or '#' that starts a value or follows
+// whitespace begins an inline comment. Values may be wrapped in double quotes,
+// which understand the escapes \", \\, \n, \r and \t, or in single quotes,
+// which are taken literally; quoted values can hold comment characters and may
+// be followed by a comment. A line ending with a backslash continues on the
+// next line.
+//
+// A section that appears several times is merged. Keys repeated within a
+// section are handled by the [CollisionPolicy] given with [WithCollisionPolicy].
+// Errors are returned as a [*DecodeError] with the line and column of the
+// problem.
+//
+//	f, _ := os.Open("legacy.ini")
+//	sections, err := cimap.ReadINI(f, cimap.WithCollisionPolicy(cimap.CollisionError))
+//	db, _ := sections.Get("Database")
+//	db.Lookup("HOST") // Output: "localhost"
+func ReadINI(r io.Reader, opts ...DecodeOption) (*CaseInsensitiveMap[*CaseInsensitiveMap[string]], error) {
+	o := newDecodeOptions(opts)
+	sections := New[*CaseInsensitiveMap[string]]()
+	if err := o.validate(sections); err != nil {
+		return nil, err
+	}
+
+	section := New[string]()
+	sections.Add("", section)
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		start := lineNo
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.ContainsRune(";#!", rune(line[0])) {
+			continue
+		}
+		for strings.HasSuffix(line, `\`) && sc.Scan() {
+			lineNo++
+			line = line[:len(line)-1] + strings.TrimSpace(sc.Text())
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return sections, &DecodeError{Line: start, Column: 1, Err: errors.New("unterminated section header")}
+			}
+			name := strings.TrimSpace(line[1:end])
+			if rest := strings.TrimSpace(line[end+1:]); rest != "" && !strings.ContainsRune(";#", rune(rest[0])) {
+				return sections, &DecodeError{Line: start, Column: len(line) - len(rest) + 1, Key: name, Err: errors.New("unexpected text after section header")}
+			}
+			var ok bool
+			if section, ok = sections.Get(name); !ok {
+				section = New[string]()
+				sections.Add(name, section)
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			return sections, &DecodeError{Line: start, Column: 1, Err: errors.New(`expected "key = value"`)}
+		}
+		key := strings.TrimSpace(line[:sep])
+		raw := strings.TrimSpace(line[sep+1:])
+		val, col, err := parseINIValue(raw)
+		if err != nil {
+			return sections, &DecodeError{Line: start, Column: len(line) - len(raw) + col + 1, Key: key, Err: err}
+		}
+		if err := section.addWithPolicy(key, val, o.collision); err != nil {
+			return sections, &DecodeError{Line: start, Column: 1, Key: key, Err: err}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return sections, &DecodeError{Line: lineNo, Err: err}
+	}
+	return sections, nil
+}
+
+// parseINIValue unquotes raw. On error it returns the 0-based column of the problem in raw.
+func parseINIValue(raw string) (string, int, error) {
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		if i := iniCommentIndex(raw); i >= 0 {
+			raw = strings.TrimSpace(raw[:i])
+		}
+		return raw, 0, nil
+	}
+
+	var (
+		sb  strings.Builder
+		end = -1
+	)
+	if raw[0] == '\'' {
+		if i := strings.IndexByte(raw[1:], '\''); i >= 0 {
+			sb.WriteString(raw[1 : i+1])
+			end = i + 1
+		}
+	} else {
+	loop:
+		for i := 1; i < len(raw); i++ {
+			switch c := raw[i]; {
+			case c == '"':
+				end = i
+				break loop
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				case '"', '\\':
+					sb.WriteByte(raw[i])
+				default:
+					return "", i - 1, errors.New("invalid escape sequence")
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+	}
+	if end < 0 {
+		return "", 0, errors.New("unterminated quoted value")
+	}
+	if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.ContainsRune(";#", rune(rest[0])) {
+		return "", end + 1, errors.New("unexpected text after quoted value")
+	}
+	return sb.String(), 0, nil
+}
+
+// WriteINI writes sections in the format read by [ReadINI].
+//
+// Sections and keys are written in insertion order with their stored casing,
+// starting with the keys of the section named "" before any header, so a file
+// read with ReadINI is written back in its original order. Values that would
+// not read back unchanged, such as those with surrounding spaces, quotes,
+// inline comments or line breaks, are written in double quotes. Comments are not preserved.
+//
+//	err := cimap.WriteINI(f, sections)
+func WriteINI(w io.Writer, sections *CaseInsensitiveMap[*CaseInsensitiveMap[string]]) error {
+	bw := bufio.NewWriter(w)
+	first := true
+	// the "" section has no header, so it must come before every other section
+	nodes := sections.nodes(OrderInsertion)
+	if i := slices.IndexFunc(nodes, func(n *node[*CaseInsensitiveMap[string]]) bool { return n.Key == "" }); i > 0 {
+		root := nodes[i]
+		copy(nodes[1:i+1], nodes[:i])
+		nodes[0] = root
+	}
+	for _, s := range nodes {
+		if s.Value == nil || s.Key == "" && s.Value.Len() == 0 {
+			continue
+		}
+		if strings.ContainsAny(s.Key, "]\n\r") || s.Key != strings.TrimSpace(s.Key) {
+			return fmt.Errorf("cimap: section name %q cannot be written as INI", s.Key)
+		}
+		if s.Key != "" {
+			if !first {
+				bw.WriteByte('\n')
+			}
+			bw.WriteString("[" + s.Key + "]\n")
+		}
+		first = false
+		for _, n := range s.Value.nodes(OrderInsertion) {
+			if !validINIKey(n.Key) {
+				return fmt.Errorf("cimap: key %q in section %q cannot be written as INI", n.Key, s.Key)
+			}
+			bw.WriteString(n.Key + " = " + quoteINIValue(n.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// iniCommentIndex returns the index of the inline comment in an unquoted value, or -1.
+func iniCommentIndex(v string) int {
+	for i := 0; i < len(v); i++ {
+		if (v[i] == ';' || v[i] == '#') && (i == 0 || v[i-1] == ' ' || v[i-1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// validINIKey reports whether ReadINI reads k back as a key.
+func validINIKey(k string) bool {
+	return k != "" && k == strings.TrimSpace(k) && !strings.ContainsAny(k, "=:\n\r") &&
+		!strings.ContainsRune(";#![", rune(k[0])) && !strings.HasSuffix(k, `\`)
+}
+
+// quoteINIValue quotes v when ReadINI would not read it back as is.
+func quoteINIValue(v string) string {
+	if v == "" || v == strings.TrimSpace(v) && !strings.ContainsAny(v, "\n\r") && v[0] != '"' && v[0] != '\'' && !strings.HasSuffix(v, `\`) && iniCommentIndex(v) < 0 {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(v) + `"`
+}
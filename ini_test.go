@@ -0,0 +1,206 @@
+package cimap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testINI = `; global settings
+Name = legacy app
+debug: true
+
+[Database]
+Host = localhost
+# the port
+PORT = 5432
+Password = "p@ss \"word\"\n" ; inline comment
+Path = 'C:\data\db'
+Timeout = 30 ; seconds
+URL = http://example.com/#frag
+Pattern = a;b	# tab before comment
+Unset = # nothing here
+
+[paths]
+Search = /usr/bin:\
+         /usr/local/bin
+Empty =
+
+[DATABASE]
+user = admin
+`
+
+func TestReadINI(t *testing.T) {
+	sections, err := cimap.ReadINI(strings.NewReader(testINI))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sections.Len())
+
+	tests := []struct {
+		section, key string
+		want         string
+		ok           bool
+	}{
+		{section: "", key: "NAME", want: "legacy app", ok: true},
+		{section: "", key: "Debug", want: "true", ok: true},
+		{section: "database", key: "host", want: "localhost", ok: true},
+		{section: "database", key: "port", want: "5432", ok: true},
+		{section: "database", key: "password", want: "p@ss \"word\"\n", ok: true},
+		{section: "database", key: "path", want: `C:\data\db`, ok: true},
+		{section: "database", key: "timeout", want: "30", ok: true},
+		{section: "database", key: "url", want: "http://example.com/#frag", ok: true},
+		{section: "database", key: "pattern", want: "a;b", ok: true},
+		{section: "database", key: "unset", want: "", ok: true},
+		{section: "Database", key: "USER", want: "admin", ok: true},
+		{section: "PATHS", key: "search", want: "/usr/bin:/usr/local/bin", ok: true},
+		{section: "paths", key: "empty", want: "", ok: true},
+		{section: "paths", key: "missing"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.section+"."+tt.key, func(t *testing.T) {
+			section, ok := sections.Get(tt.section)
+			assert.True(t, ok)
+			got, ok := section.Get(tt.key)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadINI_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    []cimap.DecodeOption
+		wantErr string
+	}{
+		{name: "Unterminated section", input: "[db\n", wantErr: "line 1, column 1: unterminated section header"},
+		{name: "Text after section", input: "\n[db] x\n", wantErr: `key "db" at line 2, column 6: unexpected text after section header`},
+		{name: "Missing separator", input: "a = 1\nb\n", wantErr: `line 2, column 1: expected "key = value"`},
+		{name: "Unterminated quote", input: `a = "b`, wantErr: `key "a" at line 1, column 5: unterminated quoted value`},
+		{name: "Bad escape", input: `a = "b\q"`, wantErr: `key "a" at line 1, column 7: invalid escape sequence`},
+		{name: "Text after quote", input: `a = 'b' c`, wantErr: `key "a" at line 1, column 8: unexpected text after quoted value`},
+		{name: "Collision error", input: "[s]\nKey = 1\nkey = 2\n", opts: []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionError)}, wantErr: `key "key" at line 3, column 1: cimap: key collision: "Key" and "key"`},
+		{name: "Nested rejected", input: "", opts: []cimap.DecodeOption{cimap.DecodeNested()}, wantErr: "nested decoding requires"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cimap.ReadINI(strings.NewReader(tt.input), tt.opts...)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorAs(t, err, new(*cimap.DecodeError))
+		})
+	}
+}
+
+func TestReadINI_Collisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy cimap.CollisionPolicy
+		want   string
+	}{
+		{name: "Replace", policy: cimap.CollisionReplace, want: "[s]\nkey = 2\n"},
+		{name: "Keep first", policy: cimap.CollisionKeepFirst, want: "[s]\nKey = 1\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := cimap.ReadINI(strings.NewReader("[s]\nKey = 1\n[S]\nkey = 2\n"), cimap.WithCollisionPolicy(tt.policy))
+			assert.NoError(t, err)
+			var sb strings.Builder
+			assert.NoError(t, cimap.WriteINI(&sb, sections))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestWriteINI(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		sections, err := cimap.ReadINI(strings.NewReader(testINI))
+		assert.NoError(t, err)
+
+		var sb strings.Builder
+		assert.NoError(t, cimap.WriteINI(&sb, sections))
+		assert.Equal(t, `Name = legacy app
+debug = true
+
+[Database]
+Host = localhost
+PORT = 5432
+Password = "p@ss \"word\"\n"
+Path = C:\data\db
+Timeout = 30
+URL = http://example.com/#frag
+Pattern = a;b
+Unset = 
+user = admin
+
+[paths]
+Search = /usr/bin:/usr/local/bin
+Empty = 
+`, sb.String())
+
+		again, err := cimap.ReadINI(strings.NewReader(sb.String()))
+		assert.NoError(t, err)
+		var sb2 strings.Builder
+		assert.NoError(t, cimap.WriteINI(&sb2, again))
+		assert.Equal(t, sb.String(), sb2.String())
+	})
+
+	t.Run("Root section added last", func(t *testing.T) {
+		db := cimap.New[string]()
+		db.Add("host", "x")
+		root := cimap.New[string]()
+		root.Add("name", "app")
+		sections := cimap.New[*cimap.CaseInsensitiveMap[string]]()
+		sections.Add("db", db)
+		sections.Add("", root)
+
+		var sb strings.Builder
+		assert.NoError(t, cimap.WriteINI(&sb, sections))
+		assert.Equal(t, "name = app\n\n[db]\nhost = x\n", sb.String())
+
+		back, err := cimap.ReadINI(strings.NewReader(sb.String()))
+		assert.NoError(t, err)
+		gotRoot, _ := back.Get("")
+		gotDB, _ := back.Get("DB")
+		assert.Equal(t, "app", gotRoot.Lookup("name"))
+		assert.False(t, gotDB.Has("name"))
+		assert.Equal(t, "x", gotDB.Lookup("host"))
+	})
+
+	t.Run("Quoting", func(t *testing.T) {
+		values := []string{" padded ", `"quoted"`, "'single'", "trailing\\", "multi\nline", "tab\tinside", "a = b ; c", "#tag", "x\t;y"}
+		section := cimap.New[string]()
+		for i, v := range values {
+			section.Add(string(rune('a'+i)), v)
+		}
+		sections := cimap.New[*cimap.CaseInsensitiveMap[string]]()
+		sections.Add("Values", section)
+
+		var sb strings.Builder
+		assert.NoError(t, cimap.WriteINI(&sb, sections))
+		back, err := cimap.ReadINI(strings.NewReader(sb.String()))
+		assert.NoError(t, err)
+		got, _ := back.Get("values")
+		for i, v := range values {
+			assert.Equal(t, v, got.Lookup(string(rune('a'+i))))
+		}
+	})
+
+	t.Run("Invalid names", func(t *testing.T) {
+		for _, tt := range []struct{ section, key string }{{"bad]", "k"}, {"s", "a=b"}, {"s", "#k"}, {"s", " k"}} {
+			section := cimap.New[string]()
+			section.Add(tt.key, "v")
+			sections := cimap.New[*cimap.CaseInsensitiveMap[string]]()
+			sections.Add(tt.section, section)
+			assert.ErrorContains(t, cimap.WriteINI(&strings.Builder{}, sections), "cannot be written as INI")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package cimap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeJSON reads a JSON object from r and stores its members in the map.
+//
+// Keys and values are decoded one at a time with a [json.Decoder], so no
+// intermediate Go map is built. Any existing data in the map is cleared first.
+// Errors are returned as a [*DecodeError] holding the byte offset where decoding
+// failed; the map then holds the members decoded so far.
+//
+//	f, _ := os.Open("aliases.json")
+//	m := cimap.New[string]()
+//	err := m.DecodeJSON(f, cimap.WithSizeHint(500_000))
+func (c *CaseInsensitiveMap[T]) DecodeJSON(r io.Reader, opts ...DecodeOption) error {
+	return c.decodeJSON(json.NewDecoder(r), newDecodeOptions(opts))
+}
+
+// decodeJSON reads a single JSON object, or null, from dec.
+func (c *CaseInsensitiveMap[T]) decodeJSON(dec *json.Decoder, o decodeOptions) error {
+	if err := o.validate(c); err != nil {
+		return err
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return &DecodeError{Offset: dec.InputOffset(), Err: err}
+	}
+	c.reset(o.sizeHint)
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return &DecodeError{Offset: dec.InputOffset(), Err: fmt.Errorf("expected object, found %v", tok)}
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return &DecodeError{Offset: dec.InputOffset(), Err: err}
+		}
+		key := tok.(string) // object keys are always strings
+
+		var val T
+		if o.nested {
+			nested, err := decodeNestedJSON(dec, o, c)
+			if err != nil {
+				return err
+			}
+			// T is any here, so only a null value fails the assertion and stays nil
+			val, _ = nested.(T)
+		} else if err := dec.Decode(&val); err != nil {
+			return &DecodeError{Offset: dec.InputOffset(), Key: key, Err: err}
+		}
+		if err := c.addWithPolicy(key, val, o.collision); err != nil {
+			return &DecodeError{Offset: dec.InputOffset(), Key: key, Err: err}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return &DecodeError{Offset: dec.InputOffset(), Err: err}
+	}
+	return nil
+}
+
+// decodeNestedJSON reads the next JSON value from dec, turning objects into
+// maps that inherit the folding of parent.
+func decodeNestedJSON[T any](dec *json.Decoder, o decodeOptions, parent *CaseInsensitiveMap[T]) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, &DecodeError{Offset: dec.InputOffset(), Err: err}
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := newWithFolding[any](parent)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, &DecodeError{Offset: dec.InputOffset(), Err: err}
+			}
+			key := tok.(string)
+			val, err := decodeNestedJSON(dec, o, parent)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.addWithPolicy(key, val, o.collision); err != nil {
+				return nil, &DecodeError{Offset: dec.InputOffset(), Key: key, Err: err}
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, &DecodeError{Offset: dec.InputOffset(), Err: err}
+		}
+		return m, nil
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			val, err := decodeNestedJSON(dec, o, parent)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, &DecodeError{Offset: dec.InputOffset(), Err: err}
+		}
+		return arr, nil
+	}
+	return tok, nil
+}
@@ -0,0 +1,238 @@
+package cimap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []cimap.DecodeOption
+		expected map[string]int
+		err      error
+	}{
+		{
+			name:     "Object",
+			input:    `{"Foo": 1, "bar": 2}`,
+			expected: map[string]int{"Foo": 1, "bar": 2},
+		},
+		{
+			name:     "Empty object",
+			input:    `{}`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "Null",
+			input:    `null`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "Size hint",
+			input:    `{"Foo": 1}`,
+			opts:     []cimap.DecodeOption{cimap.WithSizeHint(1024)},
+			expected: map[string]int{"Foo": 1},
+		},
+		{
+			name:     "Collision replace",
+			input:    `{"Foo": 1, "FOO": 2}`,
+			expected: map[string]int{"FOO": 2},
+		},
+		{
+			name:     "Collision keep first",
+			input:    `{"Foo": 1, "FOO": 2}`,
+			opts:     []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionKeepFirst)},
+			expected: map[string]int{"Foo": 1},
+		},
+		{
+			name:  "Collision error",
+			input: `{"Foo": 1, "FOO": 2}`,
+			opts:  []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionError)},
+			err:   cimap.ErrKeyCollision,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[int]()
+			m.Add("stale", 0)
+
+			err := m.DecodeJSON(strings.NewReader(tt.input), tt.opts...)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			found := make(map[string]int)
+			for k, v := range m.Iterator() {
+				found[k] = v
+			}
+			assert.Equal(t, tt.expected, found)
+		})
+	}
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		key    string
+		offset int64
+	}{
+		{
+			name:   "Not an object",
+			input:  `[1, 2]`,
+			offset: 1,
+		},
+		{
+			name:   "Wrong value type",
+			input:  `{"a": 1, "b": "two"}`,
+			key:    "b",
+			offset: 19,
+		},
+		{
+			name:   "Truncated",
+			input:  `{"a": 1, `,
+			offset: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[int]()
+			err := m.DecodeJSON(strings.NewReader(tt.input))
+
+			var derr *cimap.DecodeError
+			assert.True(t, errors.As(err, &derr), "Expected a DecodeError, got %v", err)
+			assert.Equal(t, tt.key, derr.Key)
+			assert.Equal(t, tt.offset, derr.Offset)
+		})
+	}
+}
+
+func TestUnmarshalJSON_TrailingData(t *testing.T) {
+	var m cimap.CaseInsensitiveMap[int]
+	assert.Error(t, m.UnmarshalJSON([]byte(`{"a": 1} {"b": 2}`)))
+	assert.NoError(t, json.Unmarshal([]byte(` {"a": 1} `), &m))
+	assert.Equal(t, 1, m.Len())
+}
+
+func TestDecodeJSON_Nested(t *testing.T) {
+	input := `{
+		"Server": {"Port": 8080, "TLS": {"CertFile": "/etc/cert.pem"}},
+		"Servers": [{"Name": "a"}, {"Name": "b"}],
+		"Debug": true
+	}`
+
+	cfg := cimap.New[any]()
+	assert.NoError(t, cfg.DecodeJSON(strings.NewReader(input), cimap.DecodeNested()))
+
+	tests := []struct {
+		name     string
+		path     []string
+		expected any
+		found    bool
+	}{
+		{
+			name:     "Top level",
+			path:     []string{"debug"},
+			expected: true,
+			found:    true,
+		},
+		{
+			name:     "Nested",
+			path:     []string{"SERVER", "port"},
+			expected: float64(8080),
+			found:    true,
+		},
+		{
+			name:     "Deeply nested",
+			path:     []string{"server", "tls", "certfile"},
+			expected: "/etc/cert.pem",
+			found:    true,
+		},
+		{
+			name:  "Missing key",
+			path:  []string{"server", "host"},
+			found: false,
+		},
+		{
+			name:  "Descend into scalar",
+			path:  []string{"debug", "level"},
+			found: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			val, ok := cfg.Path(tt.path...)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+
+	t.Run("Arrays hold maps", func(t *testing.T) {
+		servers, ok := cfg.Get("servers")
+		assert.True(t, ok)
+		first := servers.([]any)[0].(*cimap.CaseInsensitiveMap[any])
+		name, ok := first.Get("NAME")
+		assert.True(t, ok)
+		assert.Equal(t, "a", name)
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		encoded, err := json.Marshal(cfg)
+		assert.NoError(t, err)
+		assert.JSONEq(t, input, string(encoded))
+	})
+}
+
+func TestDecodeJSON_NestedOptions(t *testing.T) {
+	t.Run("Inherits folding", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		cfg.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+		input := `{"http_server": {"read_timeout": "5s"}}`
+		assert.NoError(t, cfg.DecodeJSON(strings.NewReader(input), cimap.DecodeNested()))
+
+		val, ok := cfg.Path("httpServer", "ReadTimeout")
+		assert.True(t, ok)
+		assert.Equal(t, "5s", val)
+	})
+
+	t.Run("Nested collision", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		input := `{"server": {"port": 1, "PORT": 2}}`
+		err := cfg.DecodeJSON(strings.NewReader(input),
+			cimap.DecodeNested(), cimap.WithCollisionPolicy(cimap.CollisionError))
+		assert.ErrorIs(t, err, cimap.ErrKeyCollision)
+	})
+
+	t.Run("Null members", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		input := `{"a": null, "b": {"c": null}}`
+		assert.NoError(t, cfg.DecodeJSON(strings.NewReader(input), cimap.DecodeNested()))
+
+		val, ok := cfg.Get("A")
+		assert.True(t, ok)
+		assert.Nil(t, val)
+		val, ok = cfg.Path("b", "C")
+		assert.True(t, ok)
+		assert.Nil(t, val)
+	})
+
+	t.Run("Requires any values", func(t *testing.T) {
+		m := cimap.New[int]()
+		assert.Error(t, m.DecodeJSON(strings.NewReader(`{"a": 1}`), cimap.DecodeNested()))
+	})
+}
@@ -0,0 +1,297 @@
+package cimap
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// MappedMap is a read-only case-insensitive map backed by a file written with
+// [WriteMapped].
+//
+// The file holds a hash index built with the same folding hash as the source
+// map, so lookups read the file in place without deserializing it. On Unix
+// systems the file is memory-mapped and its pages are shared by every process
+// that opens it. A MappedMap is safe for concurrent use until it is closed.
+type MappedMap struct {
+	data    []byte
+	release func() error
+	count   uint64
+	buckets uint64
+	index   uint64 // offset of the bucket table
+	entries uint64 // offset of the entry table
+	folding string
+	hash    func(string) hash64
+	equal   func(string, string) bool
+	closed  bool
+}
+
+// The mapped file layout, all integers little-endian:
+//
+//	header   magic "CIMM", version u32, count u64, buckets u64, folding length u32, reserved u32
+//	folding  folding mode name, padded to 8 bytes
+//	index    buckets+1 u64 entry numbers; bucket b holds entries [index[b], index[b+1])
+//	entries  count entries of hash u64, key offset u64, value offset u64, key length u32, value length u32
+//	data     keys and values
+const (
+	mappedVersion     = 1
+	mappedHeaderLen   = 32
+	mappedEntryLen    = 32
+	mappedMaxNameLen  = 1 << 10
+	mappedMaxBuckets  = 1 << 40
+	mappedMagicString = "CIMM"
+)
+
+// ErrInvalidMapped is wrapped by errors returned when opening a malformed mapped file.
+var ErrInvalidMapped = errors.New("cimap: invalid mapped file")
+
+type mappedEntry struct {
+	hash       hash64
+	key, value []byte
+}
+
+// WriteMapped writes m to w in the file format read by [OpenMapped].
+//
+// The index uses the folding hash of m, so maps with a hasher set through
+// [CaseInsensitiveMap.SetHasher] cannot be written.
+//
+//	f, _ := os.Create("dictionary.cimap")
+//	err := cimap.WriteMapped(f, dictionary)
+func WriteMapped[V ~string | ~[]byte](w io.Writer, m *CaseInsensitiveMap[V]) error {
+	if m.customHash {
+		return errors.New("cimap: cannot write a mapped file for a map with a custom hasher")
+	}
+
+	count := uint64(m.size)
+	buckets := uint64(1)
+	if count > 1 {
+		buckets = 1 << bits.Len64(count-1)
+	}
+
+	entries := make([]mappedEntry, 0, count)
+	for _, n := range m.nodes(OrderInsertion) {
+		entries = append(entries, mappedEntry{hash: m.hashString(n.Key), key: []byte(n.Key), value: []byte(n.Value)})
+	}
+	slices.SortStableFunc(entries, func(a, b mappedEntry) int {
+		return cmp.Compare(a.hash&(buckets-1), b.hash&(buckets-1))
+	})
+
+	folding := m.Folding()
+	nameLen := (uint64(len(folding)) + 7) &^ 7
+	dataOff := mappedHeaderLen + nameLen + (buckets+1)*8 + count*mappedEntryLen
+
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	buf = append(buf, mappedMagicString...)
+	buf = binary.LittleEndian.AppendUint32(buf, mappedVersion)
+	buf = binary.LittleEndian.AppendUint64(buf, count)
+	buf = binary.LittleEndian.AppendUint64(buf, buckets)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(folding)))
+	buf = binary.LittleEndian.AppendUint32(buf, 0)
+	buf = append(buf, folding...)
+	buf = append(buf, make([]byte, nameLen-uint64(len(folding)))...)
+	bw.Write(buf)
+
+	// bucket table, counting how many entries fall before each bucket
+	buf = buf[:0]
+	next := 0
+	for b := uint64(0); b <= buckets; b++ {
+		for next < len(entries) && entries[next].hash&(buckets-1) < b {
+			next++
+		}
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(next))
+	}
+	bw.Write(buf)
+
+	off := dataOff
+	for _, e := range entries {
+		buf = buf[:0]
+		buf = binary.LittleEndian.AppendUint64(buf, e.hash)
+		buf = binary.LittleEndian.AppendUint64(buf, off)
+		buf = binary.LittleEndian.AppendUint64(buf, off+uint64(len(e.key)))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(e.key)))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(e.value)))
+		bw.Write(buf)
+		off += uint64(len(e.key) + len(e.value))
+	}
+
+	for _, e := range entries {
+		bw.Write(e.key)
+		bw.Write(e.value)
+	}
+	return bw.Flush()
+}
+
+// OpenMapped opens a file written by [WriteMapped].
+//
+// Built-in folding modes are restored from the file. Maps written with a
+// custom special case or normalizer must pass the same folding options, which
+// are checked against the name stored in the file.
+//
+//	dict, err := cimap.OpenMapped("dictionary.cimap")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer dict.Close()
+//	v, ok := dict.Get("Hello")
+func OpenMapped(path string, opts ...FoldOption) (*MappedMap, error) {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mm, err := newMappedMap(data, opts)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	mm.release = release
+	return mm, nil
+}
+
+// newMappedMap validates data and prepares the lookup functions.
+func newMappedMap(data []byte, opts []FoldOption) (*MappedMap, error) {
+	size := uint64(len(data))
+	if size < mappedHeaderLen || string(data[:4]) != mappedMagicString {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidMapped)
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != mappedVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidMapped, v)
+	}
+
+	mm := &MappedMap{
+		data:    data,
+		count:   binary.LittleEndian.Uint64(data[8:]),
+		buckets: binary.LittleEndian.Uint64(data[16:]),
+	}
+	nameLen := uint64(binary.LittleEndian.Uint32(data[24:]))
+	if nameLen > mappedMaxNameLen || mm.buckets == 0 || mm.buckets > mappedMaxBuckets || mm.buckets&(mm.buckets-1) != 0 {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidMapped)
+	}
+	mm.index = mappedHeaderLen + (nameLen+7)&^7
+	mm.entries = mm.index + (mm.buckets+1)*8
+	if mm.entries > size || mm.count > (size-mm.entries)/mappedEntryLen {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidMapped)
+	}
+	mm.folding = string(data[mappedHeaderLen : mappedHeaderLen+nameLen])
+
+	if len(opts) == 0 {
+		var err error
+		if opts, err = foldingByName(mm.folding); err != nil {
+			return nil, err
+		}
+	}
+	f := newFolder(opts)
+	if name := f.name(); name != mm.folding {
+		return nil, fmt.Errorf("%w: file uses %q, options give %q", ErrFoldingMismatch, mm.folding, name)
+	}
+	mm.hash, mm.equal = f.funcs()
+
+	// validate the tables once so lookups never read out of bounds
+	prev := uint64(0)
+	for b := uint64(0); b <= mm.buckets; b++ {
+		start := binary.LittleEndian.Uint64(data[mm.index+b*8:])
+		if start < prev || start > mm.count {
+			return nil, fmt.Errorf("%w: bad bucket table", ErrInvalidMapped)
+		}
+		prev = start
+	}
+	if prev != mm.count {
+		return nil, fmt.Errorf("%w: bad bucket table", ErrInvalidMapped)
+	}
+	for i := uint64(0); i < mm.count; i++ {
+		_, keyOff, valOff, keyLen, valLen := mm.entry(i)
+		if keyOff > size || keyLen > size-keyOff || valOff > size || valLen > size-valOff {
+			return nil, fmt.Errorf("%w: entry %d out of bounds", ErrInvalidMapped, i)
+		}
+	}
+	return mm, nil
+}
+
+// entry decodes entry i of the entry table.
+func (mm *MappedMap) entry(i uint64) (h hash64, keyOff, valOff, keyLen, valLen uint64) {
+	e := mm.data[mm.entries+i*mappedEntryLen:]
+	return binary.LittleEndian.Uint64(e),
+		binary.LittleEndian.Uint64(e[8:]),
+		binary.LittleEndian.Uint64(e[16:]),
+		uint64(binary.LittleEndian.Uint32(e[24:])),
+		uint64(binary.LittleEndian.Uint32(e[28:]))
+}
+
+// Get returns the value stored for k using a case-insensitive comparison.
+//
+// The returned slice points into the mapped file: it must not be modified and
+// is only valid until [MappedMap.Close] is called.
+//
+//	v, ok := dict.Get("HELLO")
+func (mm *MappedMap) Get(k string) ([]byte, bool) {
+	if mm.closed {
+		return nil, false
+	}
+	h := mm.hash(k)
+	b := h & (mm.buckets - 1)
+	start := binary.LittleEndian.Uint64(mm.data[mm.index+b*8:])
+	end := binary.LittleEndian.Uint64(mm.data[mm.index+(b+1)*8:])
+	for i := start; i < end; i++ {
+		eh, keyOff, valOff, keyLen, valLen := mm.entry(i)
+		if eh != h || !mm.equal(string(mm.data[keyOff:keyOff+keyLen]), k) {
+			continue
+		}
+		return mm.data[valOff : valOff+valLen : valOff+valLen], true
+	}
+	return nil, false
+}
+
+// GetString is like [MappedMap.Get] but returns a copy of the value as a string.
+//
+//	v, ok := dict.GetString("hello")
+func (mm *MappedMap) GetString(k string) (string, bool) {
+	v, ok := mm.Get(k)
+	return string(v), ok
+}
+
+// Keys returns an iterator over all keys in the file, in their original casing.
+// The iteration order is unspecified.
+//
+//	for k := range dict.Keys() {
+//	    fmt.Println(k)
+//	}
+func (mm *MappedMap) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := uint64(0); i < mm.count && !mm.closed; i++ {
+			_, keyOff, _, keyLen, _ := mm.entry(i)
+			if !yield(string(mm.data[keyOff : keyOff+keyLen])) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of keys in the file.
+func (mm *MappedMap) Len() int {
+	return int(mm.count)
+}
+
+// Folding returns the name of the folding mode the file was written with.
+func (mm *MappedMap) Folding() string {
+	return mm.folding
+}
+
+// Close releases the mapping. Values returned by [MappedMap.Get] must not be
+// used afterwards.
+func (mm *MappedMap) Close() error {
+	if mm.closed {
+		return nil
+	}
+	mm.closed = true
+	mm.data = nil
+	if mm.release == nil {
+		return nil
+	}
+	return mm.release()
+}
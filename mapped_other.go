@@ -0,0 +1,14 @@
+//go:build !unix
+
+package cimap
+
+import "os"
+
+// mapFile reads the whole file at path on systems without mmap support.
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,132 @@
+package cimap_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMappedFile[V ~string | ~[]byte](t *testing.T, m *cimap.CaseInsensitiveMap[V]) string {
+	path := filepath.Join(t.TempDir(), "map.cimap")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, cimap.WriteMapped(f, m))
+	assert.NoError(t, f.Close())
+	return path
+}
+
+func TestMappedMap(t *testing.T) {
+	m := cimap.New[string]()
+	for i := range 1000 {
+		m.Add("Key"+strconv.Itoa(i), "value"+strconv.Itoa(i))
+	}
+	m.Add("Ünïcode", "✓")
+	m.Add("Empty", "")
+
+	mm, err := cimap.OpenMapped(writeMappedFile(t, m))
+	assert.NoError(t, err)
+	defer mm.Close()
+
+	assert.Equal(t, m.Len(), mm.Len())
+	assert.Equal(t, cimap.DefaultFolding, mm.Folding())
+
+	tests := []struct {
+		key      string
+		expected string
+		found    bool
+	}{
+		{"KEY0", "value0", true},
+		{"key999", "value999", true},
+		{"üNÏCODE", "✓", true},
+		{"empty", "", true},
+		{"Key1000", "", false},
+	}
+	for _, tt := range tests {
+		val, ok := mm.GetString(tt.key)
+		assert.Equal(t, tt.found, ok, "Unexpected existence for key %q", tt.key)
+		assert.Equal(t, tt.expected, val, "Value mismatch for key %q", tt.key)
+	}
+
+	var keys []string
+	for k := range mm.Keys() {
+		keys = append(keys, k)
+	}
+	assert.Len(t, keys, m.Len())
+	assert.Contains(t, keys, "Ünïcode", "Expected original casing")
+
+	assert.NoError(t, mm.Close())
+	_, ok := mm.Get("key0")
+	assert.False(t, ok, "Expected lookups to fail after Close")
+}
+
+func TestMappedMap_Folding(t *testing.T) {
+	t.Run("Built-in folding is restored", func(t *testing.T) {
+		m := cimap.New[[]byte]()
+		m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll), cimap.FoldWidth())
+		m.Add("Content-Type", []byte("text/plain"))
+
+		mm, err := cimap.OpenMapped(writeMappedFile(t, m))
+		assert.NoError(t, err)
+		defer mm.Close()
+
+		val, ok := mm.Get("ｃｏｎｔｅｎｔＴｙｐｅ")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("text/plain"), val)
+	})
+
+	t.Run("Custom folding requires options", func(t *testing.T) {
+		trim := cimap.FoldNormalizer("trim", cimap.KeyNormalizerFunc(strings.TrimSpace))
+		m := cimap.New[string]()
+		m.SetFolding(trim)
+		m.Add("Key", "value")
+		path := writeMappedFile(t, m)
+
+		_, err := cimap.OpenMapped(path)
+		assert.ErrorIs(t, err, cimap.ErrFoldingMismatch)
+
+		mm, err := cimap.OpenMapped(path, trim)
+		assert.NoError(t, err)
+		defer mm.Close()
+		_, ok := mm.Get("  KEY ")
+		assert.True(t, ok)
+	})
+
+	t.Run("Custom hasher", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.SetHasher(func(s string) uint64 { return 0 })
+		assert.Error(t, cimap.WriteMapped(&bytes.Buffer{}, m))
+	})
+}
+
+func TestMappedMap_Invalid(t *testing.T) {
+	m := cimap.New[string]()
+	m.Add("a", "b")
+	var buf bytes.Buffer
+	assert.NoError(t, cimap.WriteMapped(&buf, m))
+	data := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"Bad magic", append([]byte("XXXX"), data[4:]...)},
+		{"Truncated", data[:len(data)-20]},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bad.cimap")
+			assert.NoError(t, os.WriteFile(path, tt.data, 0o600))
+			_, err := cimap.OpenMapped(path)
+			assert.ErrorIs(t, err, cimap.ErrInvalidMapped)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package cimap
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile memory-maps the file at path read-only and shared.
+func mapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return nil, nil, fmt.Errorf("%w: empty file", ErrInvalidMapped)
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("%w: file too large", ErrInvalidMapped)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cimap: mmap %s: %w", path, err)
+	}
+	return data, func() error {
+		return syscall.Munmap(data)
+	}, nil
+}
@@ -0,0 +1,66 @@
+package cimap
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Order selects the order in which entries are written by encoders such as
+// [CaseInsensitiveMap.MarshalJSONTo].
+type Order uint8
+
+const (
+	// OrderSorted sorts keys with a case-insensitive collation, breaking ties
+	// between keys that differ only by case with a byte-wise comparison.
+	OrderSorted Order = iota
+	// OrderInsertion keeps the order in which keys were first added.
+	// Replacing the value of a key keeps its original position.
+	OrderInsertion
+	// OrderStored uses the internal storage order, which is the cheapest but
+	// is not deterministic.
+	OrderStored
+)
+
+// nodes returns every node of the map in the given order.
+func (c *CaseInsensitiveMap[T]) nodes(order Order) []*node[T] {
+	nodes := make([]*node[T], 0, c.size)
+	for _, v := range c.internalMap {
+		for ; v != nil; v = v.Next {
+			nodes = append(nodes, v)
+		}
+	}
+
+	switch order {
+	case OrderSorted:
+		slices.SortFunc(nodes, func(a, b *node[T]) int {
+			return compareFold(a.Key, b.Key)
+		})
+	case OrderInsertion:
+		slices.SortFunc(nodes, func(a, b *node[T]) int {
+			return cmp.Compare(a.Seq, b.Seq)
+		})
+	}
+	return nodes
+}
+
+// compareFold compares a and b rune by rune after lowering them, falling back
+// to a byte-wise comparison when they are equal ignoring case.
+// It avoids allocating lowered copies of the keys.
+func compareFold(a, b string) int {
+	ra, rb := a, b
+	for len(ra) > 0 && len(rb) > 0 {
+		ca, na := utf8.DecodeRuneInString(ra)
+		cb, nb := utf8.DecodeRuneInString(rb)
+		if c := cmp.Compare(unicode.ToLower(ca), unicode.ToLower(cb)); c != 0 {
+			return c
+		}
+		ra, rb = ra[na:], rb[nb:]
+	}
+	if c := cmp.Compare(len(ra), len(rb)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,252 @@
+package cimap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// anyLookup is implemented by every [CaseInsensitiveMap] regardless of its value type.
+type anyLookup interface {
+	lookupAny(k string) (any, bool)
+	sortedKeys() []string
+	equalKeys(a, b string) bool
+}
+
+func (c *CaseInsensitiveMap[T]) lookupAny(k string) (any, bool) {
+	return c.Get(k)
+}
+
+func (c *CaseInsensitiveMap[T]) sortedKeys() []string {
+	keys := make([]string, 0, c.size)
+	for _, n := range c.nodes(OrderSorted) {
+		keys = append(keys, n.Key)
+	}
+	return keys
+}
+
+// Path follows keys through nested maps, comparing every key case-insensitively.
+//
+// Each value along the path must itself be a [*CaseInsensitiveMap], as produced
+// by [DecodeNested]. It returns false as soon as a key is missing or a value
+// cannot be descended into.
+//
+//	cfg := cimap.New[any]()
+//	_ = cfg.DecodeJSON(strings.NewReader(`{"server": {"Port": 8080}}`), cimap.DecodeNested())
+//	port, ok := cfg.Path("Server", "PORT") // Output: 8080 true
+func (c *CaseInsensitiveMap[T]) Path(keys ...string) (any, bool) {
+	var cur any = c
+	for _, k := range keys {
+		m, ok := cur.(anyLookup)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m.lookupAny(k); !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// PathError reports the segment of a path that could not be resolved.
+type PathError struct {
+	Path    string // full path passed to GetPath
+	Segment string // failing segment, including any index, such as "servers[3]"
+	Err     error
+}
+
+var (
+	// ErrPathNotFound is wrapped by a [*PathError] when a key or index does not exist.
+	ErrPathNotFound = errors.New("cimap: path not found")
+	// ErrPathType is wrapped by a [*PathError] when a value has an unexpected type.
+	ErrPathType = errors.New("cimap: unexpected type")
+)
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("cimap: path %q: segment %q: %v", e.Path, e.Segment, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// GetPath resolves a dotted path such as "server.tls.CertFile" or
+// "servers[0].name" through nested maps and slices.
+//
+// Keys are matched case-insensitively in every [CaseInsensitiveMap] along the
+// way, and plain map[string]any values fall back to a case-insensitive scan.
+// Failures are returned as a [*PathError] naming the segment that failed.
+//
+//	name, err := cimap.GetPath(cfg, "SERVERS[0].Name")
+func GetPath[T any](m *CaseInsensitiveMap[T], path string) (any, error) {
+	var cur any = m
+	for _, segment := range strings.Split(path, ".") {
+		key, indices, err := parseSegment(segment)
+		if err != nil {
+			return nil, &PathError{Path: path, Segment: segment, Err: err}
+		}
+		if cur, err = lookupKey(cur, key); err != nil {
+			return nil, &PathError{Path: path, Segment: segment, Err: err}
+		}
+		for _, idx := range indices {
+			if cur, err = lookupIndex(cur, idx); err != nil {
+				return nil, &PathError{Path: path, Segment: segment, Err: err}
+			}
+		}
+	}
+	return cur, nil
+}
+
+// GetString resolves path with [GetPath] and returns the string stored there.
+//
+//	cert, err := cimap.GetString(cfg, "server.tls.certFile")
+func GetString[T any](m *CaseInsensitiveMap[T], path string) (string, error) {
+	val, err := GetPath(m, path)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	return "", typeError(path, "string", val)
+}
+
+// GetInt resolves path with [GetPath] and converts the value to an int.
+//
+// Integer types, integral floats such as those decoded from JSON, and decimal
+// strings are accepted.
+//
+//	port, err := cimap.GetInt(cfg, "server.port")
+func GetInt[T any](m *CaseInsensitiveMap[T], path string) (int, error) {
+	val, err := GetPath(m, path)
+	if err != nil {
+		return 0, err
+	}
+	if i, ok := toInt(val); ok {
+		return i, nil
+	}
+	return 0, typeError(path, "int", val)
+}
+
+// GetDuration resolves path with [GetPath] and converts the value to a [time.Duration].
+//
+// Strings are parsed with [time.ParseDuration]; integers are taken as nanoseconds.
+//
+//	timeout, err := cimap.GetDuration(cfg, "server.readTimeout")
+func GetDuration[T any](m *CaseInsensitiveMap[T], path string) (time.Duration, error) {
+	val, err := GetPath(m, path)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := val.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			return d, nil
+		}
+		return 0, typeError(path, "duration", val)
+	}
+	if n, ok := toInt(val); ok {
+		return time.Duration(n), nil
+	}
+	return 0, typeError(path, "duration", val)
+}
+
+// toInt converts integer-like values to an int.
+func toInt(val any) (int, bool) {
+	switch v := val.(type) {
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		return i, err == nil
+	case json.Number:
+		i, err := strconv.Atoi(v.String())
+		return i, err == nil
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt && v < math.MaxInt {
+			return int(v), true
+		}
+		return 0, false
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() <= math.MaxInt {
+			return int(rv.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+// parseSegment splits "servers[0][1]" into its key and indices.
+func parseSegment(segment string) (string, []int, error) {
+	open := strings.IndexByte(segment, '[')
+	if open < 0 {
+		return segment, nil, nil
+	}
+
+	key, rest := segment[:open], segment[open:]
+	var indices []int
+	for len(rest) > 0 {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, fmt.Errorf("malformed index %q", rest)
+		}
+		idx, err := strconv.Atoi(rest[1:end])
+		if err != nil || idx < 0 {
+			return "", nil, fmt.Errorf("invalid index %q", rest[1:end])
+		}
+		indices = append(indices, idx)
+		rest = rest[end+1:]
+	}
+	return key, indices, nil
+}
+
+// lookupKey returns the value stored under key in cur.
+func lookupKey(cur any, key string) (any, error) {
+	switch m := cur.(type) {
+	case anyLookup:
+		if val, ok := m.lookupAny(key); ok {
+			return val, nil
+		}
+		return nil, fmt.Errorf("%w: key %q", ErrPathNotFound, key)
+	case map[string]any:
+		if val, ok := m[key]; ok {
+			return val, nil
+		}
+		for _, k := range slices.SortedFunc(maps.Keys(m), compareFold) {
+			if strings.EqualFold(k, key) {
+				return m[k], nil
+			}
+		}
+		return nil, fmt.Errorf("%w: key %q", ErrPathNotFound, key)
+	}
+	return nil, fmt.Errorf("%w: cannot look up key %q in %T", ErrPathType, key, cur)
+}
+
+// lookupIndex returns element idx of the slice or array cur.
+func lookupIndex(cur any, idx int) (any, error) {
+	rv := reflect.ValueOf(cur)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%w: cannot index %T", ErrPathType, cur)
+	}
+	if idx >= rv.Len() {
+		return nil, fmt.Errorf("%w: index %d out of range (len %d)", ErrPathNotFound, idx, rv.Len())
+	}
+	return rv.Index(idx).Interface(), nil
+}
+
+func typeError(path, want string, val any) error {
+	return &PathError{Path: path, Segment: path[strings.LastIndexByte(path, '.')+1:], Err: fmt.Errorf("%w: want %s, got %T", ErrPathType, want, val)}
+}
@@ -0,0 +1,149 @@
+package cimap_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPathConfig(t *testing.T) *cimap.CaseInsensitiveMap[any] {
+	input := `{
+		"Server": {"Port": 8080, "ReadTimeout": "5s", "TLS": {"CertFile": "/etc/cert.pem"}},
+		"Servers": [{"Name": "a"}, {"Name": "b", "Tags": ["x", "y"]}],
+		"Retries": "3"
+	}`
+	cfg := cimap.New[any]()
+	assert.NoError(t, cfg.DecodeJSON(strings.NewReader(input), cimap.DecodeNested()))
+	cfg.Add("Plain", map[string]any{"Inner": 1})
+	cfg.Add("Wait", 2*time.Second)
+	return cfg
+}
+
+func TestGetPath(t *testing.T) {
+	cfg := newPathConfig(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected any
+		err      error
+		segment  string
+	}{
+		{
+			name:     "Nested keys",
+			path:     "server.tls.CERTFILE",
+			expected: "/etc/cert.pem",
+		},
+		{
+			name:     "Index",
+			path:     "servers[1].name",
+			expected: "b",
+		},
+		{
+			name:     "Double index",
+			path:     "SERVERS[1].tags[0]",
+			expected: "x",
+		},
+		{
+			name:     "Plain Go map",
+			path:     "plain.inner",
+			expected: 1,
+		},
+		{
+			name:    "Missing key",
+			path:    "server.tls.keyFile",
+			err:     cimap.ErrPathNotFound,
+			segment: "keyFile",
+		},
+		{
+			name:    "Index out of range",
+			path:    "servers[3].name",
+			err:     cimap.ErrPathNotFound,
+			segment: "servers[3]",
+		},
+		{
+			name:    "Index into map",
+			path:    "server[0]",
+			err:     cimap.ErrPathType,
+			segment: "server[0]",
+		},
+		{
+			name:    "Key into scalar",
+			path:    "server.port.value",
+			err:     cimap.ErrPathType,
+			segment: "value",
+		},
+		{
+			name:    "Malformed index",
+			path:    "servers[x]",
+			segment: "servers[x]",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := cimap.GetPath(cfg, tt.path)
+			if tt.segment == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, val)
+				return
+			}
+
+			var perr *cimap.PathError
+			assert.True(t, errors.As(err, &perr), "Expected a PathError, got %v", err)
+			assert.Equal(t, tt.path, perr.Path)
+			assert.Equal(t, tt.segment, perr.Segment)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestGetPath_TypedAccessors(t *testing.T) {
+	cfg := newPathConfig(t)
+
+	t.Run("GetString", func(t *testing.T) {
+		s, err := cimap.GetString(cfg, "servers[0].NAME")
+		assert.NoError(t, err)
+		assert.Equal(t, "a", s)
+
+		_, err = cimap.GetString(cfg, "server.port")
+		assert.ErrorIs(t, err, cimap.ErrPathType)
+	})
+
+	t.Run("GetInt", func(t *testing.T) {
+		port, err := cimap.GetInt(cfg, "server.port")
+		assert.NoError(t, err)
+		assert.Equal(t, 8080, port)
+
+		retries, err := cimap.GetInt(cfg, "retries")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, retries)
+
+		_, err = cimap.GetInt(cfg, "server.tls.certFile")
+		assert.ErrorIs(t, err, cimap.ErrPathType)
+	})
+
+	t.Run("GetDuration", func(t *testing.T) {
+		d, err := cimap.GetDuration(cfg, "server.readTimeout")
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, d)
+
+		d, err = cimap.GetDuration(cfg, "wait")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, d)
+
+		_, err = cimap.GetDuration(cfg, "servers[0].name")
+		assert.ErrorIs(t, err, cimap.ErrPathType)
+
+		_, err = cimap.GetDuration(cfg, "server.missing")
+		assert.ErrorIs(t, err, cimap.ErrPathNotFound)
+	})
+}
@@ -0,0 +1,178 @@
+package cimap
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// QueryValues holds query parameters whose names are compared case-insensitively,
+// so that ?PageSize=10 and ?pagesize=10 are the same parameter.
+//
+// Each name keeps the casing it was first seen with. The zero value is an
+// empty set of parameters ready to use.
+type QueryValues struct {
+	m *CaseInsensitiveMap[[]string]
+}
+
+// ParseQuery parses a URL-encoded query string like [url.ParseQuery].
+//
+// Parameters are read in the order they appear, so values keep their wire
+// order and names that only differ in case keep the first spelling. Like
+// [url.ParseQuery], it returns the parameters it could parse along with the
+// first error encountered.
+//
+//	q, err := cimap.ParseQuery("PageSize=10&sort=name")
+//	q.Get("pagesize") // Output: "10"
+func ParseQuery(query string) (*QueryValues, error) {
+	q := &QueryValues{m: New[[]string]()}
+	var firstErr error
+	for query != "" {
+		var pair string
+		pair, query, _ = strings.Cut(query, "&")
+		if strings.Contains(pair, ";") {
+			if firstErr == nil {
+				firstErr = errors.New("cimap: invalid semicolon separator in query")
+			}
+			continue
+		}
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err == nil {
+			value, err = url.QueryUnescape(value)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		q.Add(key, value)
+	}
+	return q, firstErr
+}
+
+// FromValues copies vals into a new [QueryValues], merging names that only
+// differ in case. [url.Values] does not record the order of its names, so
+// merged names are taken in sorted order; use [ParseQuery] on the raw query to
+// keep the wire order.
+//
+//	q := cimap.FromValues(r.URL.Query())
+func FromValues(vals url.Values) *QueryValues {
+	q := &QueryValues{m: New[[]string](len(vals))}
+	for _, k := range slices.Sorted(maps.Keys(vals)) {
+		q.Add(k, vals[k]...)
+	}
+	return q
+}
+
+// Get returns the first value of the parameter, or "" if there is none.
+//
+//	q.Get("PAGESIZE") // Output: "10"
+func (q *QueryValues) Get(k string) string {
+	if vals := q.All(k); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// All returns every value of the parameter, in the order they were added.
+//
+//	q, _ := cimap.ParseQuery("tag=a&TAG=b")
+//	q.All("Tag") // Output: [a b]
+func (q *QueryValues) All(k string) []string {
+	if q.m == nil {
+		return nil
+	}
+	vals, _ := q.m.Get(k)
+	return vals
+}
+
+// Has reports whether the parameter is present.
+func (q *QueryValues) Has(k string) bool {
+	return q.m != nil && q.m.Has(k)
+}
+
+// Add appends values to the parameter, adding it if it is not present.
+//
+//	q.Add("tag", "a", "b")
+func (q *QueryValues) Add(k string, vals ...string) {
+	if q.m == nil {
+		q.m = New[[]string]()
+	}
+	if n := q.m.getNode(k); n != nil {
+		n.Value = append(slices.Clip(n.Value), vals...)
+		return
+	}
+	q.m.Add(k, slices.Clone(vals))
+}
+
+// Set replaces the values of the parameter. The name keeps its existing casing.
+//
+//	q.Set("PageSize", "20")
+func (q *QueryValues) Set(k string, vals ...string) {
+	if q.m == nil {
+		q.m = New[[]string]()
+	}
+	if n := q.m.getNode(k); n != nil {
+		n.Value = slices.Clone(vals)
+		return
+	}
+	q.m.Add(k, slices.Clone(vals))
+}
+
+// Del removes the parameter.
+func (q *QueryValues) Del(k string) {
+	if q.m != nil {
+		q.m.Delete(k)
+	}
+}
+
+// Len returns the number of distinct parameters.
+func (q *QueryValues) Len() int {
+	if q.m == nil {
+		return 0
+	}
+	return q.m.Len()
+}
+
+// Values returns the parameters as [url.Values], keyed by their stored casing.
+func (q *QueryValues) Values() url.Values {
+	vals := make(url.Values, q.Len())
+	if q.m != nil {
+		for _, n := range q.m.nodes(OrderInsertion) {
+			vals[n.Key] = slices.Clone(n.Value)
+		}
+	}
+	return vals
+}
+
+// Encode encodes the parameters in URL-encoded form. Names are sorted
+// case-insensitively, values keep the order they were added in, so equal
+// parameters always encode to the same string.
+//
+//	q, _ := cimap.ParseQuery("b=2&A=1&a=0")
+//	q.Encode() // Output: "A=1&A=0&b=2"
+func (q *QueryValues) Encode() string {
+	if q.m == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, n := range q.m.nodes(OrderSorted) {
+		key := url.QueryEscape(n.Key)
+		for _, v := range n.Value {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			sb.WriteString(key)
+			sb.WriteByte('=')
+			sb.WriteString(url.QueryEscape(v))
+		}
+	}
+	return sb.String()
+}
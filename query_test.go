@@ -0,0 +1,86 @@
+package cimap_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		key     string
+		want    []string
+		encoded string
+		wantErr bool
+	}{
+		{name: "Single", query: "PageSize=10", key: "pagesize", want: []string{"10"}, encoded: "PageSize=10"},
+		{name: "Merged names", query: "pagesize=2&PageSize=1", key: "PAGESIZE", want: []string{"2", "1"}, encoded: "pagesize=2&pagesize=1"},
+		{name: "Wire order and first spelling", query: "tag=a&TAG=b&Tag=c", key: "tag", want: []string{"a", "b", "c"}, encoded: "tag=a&tag=b&tag=c"},
+		{name: "Empty pairs and values", query: "&a=&&b", key: "B", want: []string{""}, encoded: "a=&b="},
+		{name: "Semicolon", query: "a=1;b=2&c=3", key: "c", want: []string{"3"}, encoded: "c=3", wantErr: true},
+		{name: "Repeated name", query: "tag=b&tag=a", key: "Tag", want: []string{"b", "a"}, encoded: "tag=b&tag=a"},
+		{name: "Escaping", query: "q=a+b%26c", key: "Q", want: []string{"a b&c"}, encoded: "q=a+b%26c"},
+		{name: "Missing", query: "a=1", key: "b", encoded: "a=1"},
+		{name: "Invalid", query: "a=1&b=%zz", key: "a", want: []string{"1"}, encoded: "a=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := cimap.ParseQuery(tt.query)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, q.All(tt.key))
+			assert.Equal(t, tt.want != nil, q.Has(tt.key))
+			if tt.want != nil {
+				assert.Equal(t, tt.want[0], q.Get(tt.key))
+			} else {
+				assert.Equal(t, "", q.Get(tt.key))
+			}
+			assert.Equal(t, tt.encoded, q.Encode())
+		})
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	t.Run("Zero value", func(t *testing.T) {
+		var q cimap.QueryValues
+		assert.Equal(t, 0, q.Len())
+		assert.False(t, q.Has("a"))
+		assert.Equal(t, "", q.Encode())
+		q.Del("a")
+
+		q.Add("Sort", "name")
+		q.Add("sort", "age")
+		q.Set("Limit", "5")
+		assert.Equal(t, []string{"name", "age"}, q.All("SORT"))
+		assert.Equal(t, "Limit=5&Sort=name&Sort=age", q.Encode())
+
+		q.Set("LIMIT", "10")
+		q.Del("sort")
+		assert.Equal(t, url.Values{"Limit": {"10"}}, q.Values())
+	})
+
+	t.Run("Stable encoding", func(t *testing.T) {
+		vals := url.Values{"b": {"2"}, "A": {"1"}, "a": {"0"}, "c d": {"x/y"}}
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, "A=1&A=0&b=2&c+d=x%2Fy", cimap.FromValues(vals).Encode())
+		}
+	})
+
+	t.Run("Source unchanged", func(t *testing.T) {
+		vals := url.Values{"a": {"1"}}
+		q := cimap.FromValues(vals)
+		q.Add("A", "2")
+		assert.Equal(t, url.Values{"a": {"1"}}, vals)
+		assert.Equal(t, []string{"1", "2"}, q.All("a"))
+	})
+}
@@ -0,0 +1,55 @@
+package cimap
+
+import "log/slog"
+
+// RedactedValue replaces the values of redacted keys in log output.
+const RedactedValue = "[REDACTED]"
+
+// RedactedMap is a view of a map for logging that masks the values of
+// selected keys. It is created with [CaseInsensitiveMap.Redacted].
+type RedactedMap[T any] struct {
+	m      *CaseInsensitiveMap[T]
+	redact *CaseInsensitiveMap[struct{}]
+}
+
+// LogValue implements the [slog.LogValuer] interface.
+//
+// The map is logged as a group with one attribute per key, in [OrderSorted]
+// and with the original casing of keys.
+//
+//	logger.Info("request", "headers", headers) // Output: headers.Accept=*/* headers.Host=example.com
+func (c *CaseInsensitiveMap[T]) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, c.size)
+	for _, n := range c.nodes(OrderSorted) {
+		attrs = append(attrs, slog.Any(n.Key, n.Value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Redacted returns a view of the map for logging in which the values of the
+// given keys are replaced by [RedactedValue].
+//
+// Keys are matched with the folding of the map, so "authorization" also masks
+// "Authorization". Only top-level keys are masked.
+//
+//	logger.Info("request", "headers", headers.Redacted("Authorization", "Cookie"))
+func (c *CaseInsensitiveMap[T]) Redacted(keys ...string) RedactedMap[T] {
+	redact := newWithFolding[struct{}](c)
+	for _, k := range keys {
+		redact.Add(k, struct{}{})
+	}
+	return RedactedMap[T]{m: c, redact: redact}
+}
+
+// LogValue implements the [slog.LogValuer] interface.
+func (r RedactedMap[T]) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, r.m.size)
+	for _, n := range r.m.nodes(OrderSorted) {
+		if _, ok := r.redact.Get(n.Key); ok {
+			attrs = append(attrs, slog.String(n.Key, RedactedValue))
+			continue
+		}
+		attrs = append(attrs, slog.Any(n.Key, n.Value))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,59 @@
+package cimap_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ slog.LogValuer = (*cimap.CaseInsensitiveMap[string])(nil)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestLogValue(t *testing.T) {
+	headers := cimap.New[string]()
+	headers.Add("Host", "example.com")
+	headers.Add("Authorization", "Bearer secret")
+	headers.Add("accept", "*/*")
+
+	t.Run("Group", func(t *testing.T) {
+		var buf bytes.Buffer
+		newTestLogger(&buf).Info("request", "headers", headers)
+		assert.Equal(t, `msg=request headers.accept=*/* headers.Authorization="Bearer secret" headers.Host=example.com`+"\n", buf.String())
+	})
+
+	t.Run("Redacted", func(t *testing.T) {
+		var buf bytes.Buffer
+		newTestLogger(&buf).Info("request", "headers", headers.Redacted("AUTHORIZATION", "cookie"))
+		assert.Equal(t, `msg=request headers.accept=*/* headers.Authorization=[REDACTED] headers.Host=example.com`+"\n", buf.String())
+	})
+
+	t.Run("Redacted uses map folding", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.SetFolding(cimap.FoldSeparators(cimap.SeparatorAll))
+		m.Add("X-Api-Key", "secret")
+
+		var buf bytes.Buffer
+		newTestLogger(&buf).Info("call", "meta", m.Redacted("x_api_key"))
+		assert.Equal(t, "msg=call meta.X-Api-Key=[REDACTED]\n", buf.String())
+	})
+
+	t.Run("Nested maps", func(t *testing.T) {
+		var buf bytes.Buffer
+		newTestLogger(&buf).Info("cfg", "cfg", newNestedMap())
+		assert.Equal(t, "msg=cfg cfg.Outer.Inner=1\n", buf.String())
+	})
+}
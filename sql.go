@@ -0,0 +1,37 @@
+package cimap
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Scan implements the [sql.Scanner] interface for JSON and JSONB columns.
+//
+// It accepts []byte and string values holding a JSON object, decoded like
+// [CaseInsensitiveMap.UnmarshalJSON]. A NULL column leaves the map empty.
+//
+//	attrs := cimap.New[string]()
+//	err := db.QueryRow(`SELECT attrs FROM users WHERE id = $1`, id).Scan(attrs)
+func (c *CaseInsensitiveMap[T]) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		c.reset(0)
+		return nil
+	case []byte:
+		return c.UnmarshalJSON(v)
+	case string:
+		return c.UnmarshalJSON([]byte(v))
+	}
+	return fmt.Errorf("cimap: cannot scan %T into a CaseInsensitiveMap", src)
+}
+
+// Value implements the [driver.Valuer] interface, storing the map as a JSON
+// object. A nil map is stored as NULL.
+//
+//	_, err := db.Exec(`UPDATE users SET attrs = $1 WHERE id = $2`, attrs, id)
+func (c *CaseInsensitiveMap[T]) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return c.MarshalJSON()
+}
@@ -0,0 +1,115 @@
+package cimap_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memDriver is a tiny database/sql driver storing one JSON column per id.
+// It understands "INSERT id value" and "SELECT id" statements.
+type (
+	memDriver struct {
+		mu   sync.Mutex
+		rows map[int64]driver.Value
+	}
+	memConn struct{ d *memDriver }
+	memStmt struct {
+		d     *memDriver
+		query string
+	}
+	memRows struct {
+		val  driver.Value
+		done bool
+	}
+)
+
+var testDriver = &memDriver{rows: make(map[int64]driver.Value)}
+
+func init() {
+	sql.Register("cimap-mem", testDriver)
+}
+
+func (d *memDriver) Open(string) (driver.Conn, error)    { return &memConn{d}, nil }
+func (c *memConn) Prepare(q string) (driver.Stmt, error) { return &memStmt{c.d, q}, nil }
+func (c *memConn) Close() error                          { return nil }
+func (c *memConn) Begin() (driver.Tx, error)             { return nil, fmt.Errorf("not supported") }
+func (s *memStmt) Close() error                          { return nil }
+func (s *memStmt) NumInput() int                         { return strings.Count(s.query, "?") }
+func (r *memRows) Columns() []string                     { return []string{"value"} }
+func (r *memRows) Close() error                          { return nil }
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &memRows{val: s.d.rows[args[0].(int64)]}, nil
+}
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.rows[args[0].(int64)] = args[1]
+	return driver.RowsAffected(1), nil
+}
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.val
+	return nil
+}
+
+func TestSQLScanValue(t *testing.T) {
+	db, err := sql.Open("cimap-mem", "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	t.Run("Round trip", func(t *testing.T) {
+		attrs := cimap.New[string]()
+		attrs.Add("Content-Type", "text/plain")
+		_, err := db.Exec("INSERT ? ?", 1, attrs)
+		assert.NoError(t, err)
+
+		got := cimap.New[string]()
+		assert.NoError(t, db.QueryRow("SELECT ?", 1).Scan(got))
+		val, ok := got.Get("content-type")
+		assert.True(t, ok)
+		assert.Equal(t, "text/plain", val)
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		var attrs *cimap.CaseInsensitiveMap[string]
+		_, err := db.Exec("INSERT ? ?", 2, attrs)
+		assert.NoError(t, err)
+
+		got := cimap.New[string]()
+		got.Add("stale", "x")
+		assert.NoError(t, db.QueryRow("SELECT ?", 2).Scan(got))
+		assert.Equal(t, 0, got.Len())
+	})
+
+	t.Run("String column", func(t *testing.T) {
+		_, err := db.Exec("INSERT ? ?", 3, `{"A": "1"}`)
+		assert.NoError(t, err)
+
+		got := cimap.New[string]()
+		assert.NoError(t, db.QueryRow("SELECT ?", 3).Scan(got))
+		val, _ := got.Get("a")
+		assert.Equal(t, "1", val)
+	})
+}
+
+func TestScan_Errors(t *testing.T) {
+	m := cimap.New[int]()
+	assert.Error(t, m.Scan(42))
+	assert.Error(t, m.Scan([]byte(`{"a": "not a number"}`)))
+}
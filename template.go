@@ -0,0 +1,54 @@
+package cimap
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// FuncMap returns template functions for reading a [CaseInsensitiveMap] of any
+// value type. It can be passed to both text/template and html/template.
+//
+//   - cimapGet map key returns the value stored for key, or the zero value
+//   - cimapHas map key reports whether key is present
+//   - cimapKeys map returns the keys in case-insensitive sorted order
+//
+// Each function fails the template execution when map is not a [*CaseInsensitiveMap].
+//
+//	tmpl := template.Must(template.New("").Funcs(cimap.FuncMap()).Parse(
+//	    `{{ cimapGet .Headers "content-type" }}`))
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"cimapGet": func(m any, key string) (any, error) {
+			l, err := templateMap("cimapGet", m)
+			if err != nil {
+				return nil, err
+			}
+			v, _ := l.lookupAny(key)
+			return v, nil
+		},
+		"cimapHas": func(m any, key string) (bool, error) {
+			l, err := templateMap("cimapHas", m)
+			if err != nil {
+				return false, err
+			}
+			_, ok := l.lookupAny(key)
+			return ok, nil
+		},
+		"cimapKeys": func(m any) ([]string, error) {
+			l, err := templateMap("cimapKeys", m)
+			if err != nil {
+				return nil, err
+			}
+			return l.sortedKeys(), nil
+		},
+	}
+}
+
+// templateMap asserts that the argument of the template function fn is a map.
+func templateMap(fn string, m any) (anyLookup, error) {
+	l, ok := m.(anyLookup)
+	if !ok {
+		return nil, fmt.Errorf("cimap: %s: expected *cimap.CaseInsensitiveMap, got %T", fn, m)
+	}
+	return l, nil
+}
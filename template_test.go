@@ -0,0 +1,62 @@
+package cimap_test
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncMap(t *testing.T) {
+	headers := cimap.New[string]()
+	headers.Add("Content-Type", "text/html")
+	headers.Add("X-Request-ID", "<42>")
+	headers.Add("accept", "*/*")
+
+	counts := cimap.New[int]()
+	counts.Add("Hits", 7)
+
+	data := map[string]any{"Headers": headers, "Counts": counts, "Plain": map[string]string{"a": "b"}}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{name: "Get", tmpl: `{{ cimapGet .Headers "content-type" }}`, want: "text/html"},
+		{name: "Get int", tmpl: `{{ cimapGet .Counts "HITS" }}`, want: "7"},
+		{name: "Get missing", tmpl: `[{{ cimapGet .Headers "missing" }}]`, want: "[]"},
+		{name: "Has", tmpl: `{{ cimapHas .Headers "ACCEPT" }} {{ cimapHas .Headers "cookie" }}`, want: "true false"},
+		{name: "Keys", tmpl: `{{ range cimapKeys .Headers }}{{ . }};{{ end }}`, want: "accept;Content-Type;X-Request-ID;"},
+		{name: "Lookup method", tmpl: `{{ .Headers.Lookup "x-request-id" }}`, want: "<42>"},
+		{name: "Has method", tmpl: `{{ if .Counts.Has "hits" }}yes{{ end }}`, want: "yes"},
+		{name: "Not a map", tmpl: `{{ cimapGet .Plain "a" }}`, wantErr: "cimapGet: expected *cimap.CaseInsensitiveMap, got map[string]string"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("").Funcs(cimap.FuncMap()).Parse(tt.tmpl))
+			var sb strings.Builder
+			err := tmpl.Execute(&sb, data)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+
+	t.Run("HTML escaping", func(t *testing.T) {
+		tmpl := htmltemplate.Must(htmltemplate.New("").Funcs(cimap.FuncMap()).Parse(`<p>{{ cimapGet .Headers "X-REQUEST-ID" }}</p>`))
+		var sb strings.Builder
+		assert.NoError(t, tmpl.Execute(&sb, data))
+		assert.Equal(t, "<p>&lt;42&gt;</p>", sb.String())
+	})
+}
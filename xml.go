@@ -0,0 +1,137 @@
+package cimap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// XMLEntries encodes a map as a list of key/value elements such as
+//
+//	<settings>
+//	  <entry key="Timeout">30s</entry>
+//	  <entry key="Retries">3</entry>
+//	</settings>
+//
+// with configurable element and attribute names. The map itself implements
+// [xml.Marshaler] and [xml.Unmarshaler] with the default names; wrap it in an
+// XMLEntries to change them or to choose a [CollisionPolicy].
+//
+//	out, err := xml.Marshal(cimap.XMLEntries[string]{Map: m, Element: "setting", Attr: "name"})
+type XMLEntries[T any] struct {
+	Map       *CaseInsensitiveMap[T]
+	Element   string          // name of each entry element, "entry" if empty
+	Attr      string          // name of the key attribute, "key" if empty
+	Collision CollisionPolicy // how keys that differ only by case are decoded
+}
+
+const (
+	defaultXMLElement = "entry"
+	defaultXMLAttr    = "key"
+)
+
+// MarshalXML implements the [xml.Marshaler] interface.
+//
+// It writes one <entry key="..."> element per key, in insertion order and with
+// the original casing of keys. Use [XMLEntries] to change the element names.
+//
+//	out, err := xml.Marshal(m) // Output: <CaseInsensitiveMap><entry key="Key">123</entry></CaseInsensitiveMap>
+func (c *CaseInsensitiveMap[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return XMLEntries[T]{Map: c}.MarshalXML(e, start)
+}
+
+// UnmarshalXML implements the [xml.Unmarshaler] interface.
+//
+// It reads <entry key="..."> elements into the map using case-insensitive key
+// handling. Any existing data in the map is cleared before unmarshalling.
+//
+//	var m cimap.CaseInsensitiveMap[string]
+//	err := xml.Unmarshal([]byte(`<settings><entry key="A">1</entry></settings>`), &m)
+func (c *CaseInsensitiveMap[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return XMLEntries[T]{Map: c}.UnmarshalXML(d, start)
+}
+
+// MarshalXML implements the [xml.Marshaler] interface.
+func (x XMLEntries[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	element, attr := x.names()
+	// generic type names such as "CaseInsensitiveMap[int]" are not valid element names
+	if i := strings.IndexByte(start.Name.Local, '['); i >= 0 {
+		start.Name.Local = start.Name.Local[:i]
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, n := range x.Map.nodes(OrderInsertion) {
+		entry := xml.StartElement{
+			Name: xml.Name{Local: element},
+			Attr: []xml.Attr{{Name: xml.Name{Local: attr}, Value: n.Key}},
+		}
+		if err := e.EncodeElement(n.Value, entry); err != nil {
+			return fmt.Errorf("cimap: encoding value of %q: %w", n.Key, err)
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements the [xml.Unmarshaler] interface.
+//
+// Elements other than the entry element are skipped. Entries without the key
+// attribute and rejected collisions return a [*DecodeError] with the line and
+// column of the entry.
+func (x XMLEntries[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	element, attr := x.names()
+	x.Map.reset(0)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			line, column := d.InputPos()
+			if t.Name.Local != element {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			key, ok := xmlAttr(t, attr)
+			if !ok {
+				return &DecodeError{Offset: d.InputOffset(), Line: line, Column: column, Err: fmt.Errorf("<%s> without %q attribute", element, attr)}
+			}
+			var val T
+			if err := d.DecodeElement(&val, &t); err != nil {
+				return &DecodeError{Offset: d.InputOffset(), Line: line, Column: column, Key: key, Err: err}
+			}
+			if err := x.Map.addWithPolicy(key, val, x.Collision); err != nil {
+				return &DecodeError{Offset: d.InputOffset(), Line: line, Column: column, Key: key, Err: err}
+			}
+		}
+	}
+}
+
+// names returns the element and attribute names, applying defaults.
+func (x XMLEntries[T]) names() (string, string) {
+	element, attr := x.Element, x.Attr
+	if element == "" {
+		element = defaultXMLElement
+	}
+	if attr == "" {
+		attr = defaultXMLAttr
+	}
+	return element, attr
+}
+
+// xmlAttr returns the value of the attribute called name.
+func xmlAttr(se xml.StartElement, name string) (string, bool) {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,112 @@
+package cimap_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshalXML(t *testing.T) {
+	t.Run("Round trip", func(t *testing.T) {
+		type settings struct {
+			XMLName xml.Name                          `xml:"settings"`
+			Values  *cimap.CaseInsensitiveMap[string] `xml:"values"`
+		}
+		in := settings{Values: cimap.New[string]()}
+		in.Values.Add("Timeout", "30s")
+		in.Values.Add("retries", "3")
+
+		out, err := xml.Marshal(in)
+		assert.NoError(t, err)
+		assert.Equal(t, `<settings><values><entry key="Timeout">30s</entry><entry key="retries">3</entry></values></settings>`, string(out))
+
+		var back settings
+		assert.NoError(t, xml.Unmarshal(out, &back))
+		val, ok := back.Values.Get("TIMEOUT")
+		assert.True(t, ok)
+		assert.Equal(t, "30s", val)
+	})
+
+	t.Run("Struct values", func(t *testing.T) {
+		type limit struct {
+			Max int `xml:"max"`
+		}
+		m := cimap.New[limit]()
+		m.Add("API", limit{Max: 10})
+
+		out, err := xml.Marshal(m)
+		assert.NoError(t, err)
+
+		var back cimap.CaseInsensitiveMap[limit]
+		assert.NoError(t, xml.Unmarshal(out, &back))
+		val, ok := back.Get("api")
+		assert.True(t, ok)
+		assert.Equal(t, 10, val.Max)
+	})
+
+	t.Run("Skips unknown elements", func(t *testing.T) {
+		var m cimap.CaseInsensitiveMap[int]
+		data := `<root><comment>ignored <b>too</b></comment><entry key="A">1</entry></root>`
+		assert.NoError(t, xml.Unmarshal([]byte(data), &m))
+		assert.Equal(t, 1, m.Len())
+	})
+}
+
+func TestXMLEntries(t *testing.T) {
+	t.Run("Custom names", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.Add("Mode", "fast")
+
+		out, err := xml.Marshal(cimap.XMLEntries[string]{Map: m, Element: "setting", Attr: "name"})
+		assert.NoError(t, err)
+		assert.Equal(t, `<XMLEntries><setting name="Mode">fast</setting></XMLEntries>`, string(out))
+
+		back := cimap.New[string]()
+		assert.NoError(t, xml.Unmarshal(out, &cimap.XMLEntries[string]{Map: back, Element: "setting", Attr: "name"}))
+		val, ok := back.Get("MODE")
+		assert.True(t, ok)
+		assert.Equal(t, "fast", val)
+	})
+
+	data := []byte("<root>\n  <entry key=\"Mode\">fast</entry>\n  <entry key=\"MODE\">slow</entry>\n</root>")
+
+	tests := []struct {
+		name      string
+		collision cimap.CollisionPolicy
+		expected  string
+	}{
+		{"Replace", cimap.CollisionReplace, "slow"},
+		{"Keep first", cimap.CollisionKeepFirst, "fast"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := cimap.New[string]()
+			assert.NoError(t, xml.Unmarshal(data, &cimap.XMLEntries[string]{Map: m, Collision: tt.collision}))
+			val, _ := m.Get("mode")
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+
+	t.Run("Collision error", func(t *testing.T) {
+		m := cimap.New[string]()
+		err := xml.Unmarshal(data, &cimap.XMLEntries[string]{Map: m, Collision: cimap.CollisionError})
+		assert.ErrorIs(t, err, cimap.ErrKeyCollision)
+
+		var derr *cimap.DecodeError
+		assert.True(t, errors.As(err, &derr))
+		assert.Equal(t, "MODE", derr.Key)
+		assert.Equal(t, 3, derr.Line)
+	})
+
+	t.Run("Missing key attribute", func(t *testing.T) {
+		m := cimap.New[string]()
+		err := xml.Unmarshal([]byte(`<root><entry>x</entry></root>`), m)
+		var derr *cimap.DecodeError
+		assert.True(t, errors.As(err, &derr))
+	})
+}
@@ -0,0 +1,223 @@
+package cimap
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML implements the [yaml.Marshaler] interface.
+//
+// It encodes the map as a YAML mapping in insertion order, preserving the
+// original casing of keys.
+//
+//	data, err := yaml.Marshal(m) // Output: Key: 123
+func (c *CaseInsensitiveMap[T]) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, n := range c.nodes(OrderInsertion) {
+		var val yaml.Node
+		if err := val.Encode(n.Value); err != nil {
+			return nil, fmt.Errorf("cimap: encoding value of %q: %w", n.Key, err)
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.Key}
+		node.Content = append(node.Content, key, &val)
+	}
+	return node, nil
+}
+
+// UnmarshalYAML implements the [yaml.Unmarshaler] interface.
+//
+// It decodes a YAML mapping into the map in document order using
+// case-insensitive key handling. Any existing data in the map is cleared.
+//
+//	var m cimap.CaseInsensitiveMap[int]
+//	err := yaml.Unmarshal([]byte("Foo: 10\nbar: 20\n"), &m)
+func (c *CaseInsensitiveMap[T]) UnmarshalYAML(node *yaml.Node) error {
+	return c.decodeYAML(node, newDecodeOptions(nil))
+}
+
+// DecodeYAML reads a YAML document from r and stores its mapping in the map.
+//
+// It accepts the same options as [CaseInsensitiveMap.DecodeJSON]. Merge keys
+// ("<<") are expanded, with keys written in a mapping overriding merged ones. Errors are
+// returned as a [*DecodeError] holding the line and column of the offending
+// key, so collisions rejected by [CollisionError] point at the second key.
+//
+//	f, _ := os.Open("config.yaml")
+//	cfg := cimap.New[any]()
+//	err := cfg.DecodeYAML(f, cimap.DecodeNested(), cimap.WithCollisionPolicy(cimap.CollisionError))
+func (c *CaseInsensitiveMap[T]) DecodeYAML(r io.Reader, opts ...DecodeOption) error {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			c.reset(0)
+			return nil
+		}
+		return &DecodeError{Err: err}
+	}
+	return c.decodeYAML(&doc, newDecodeOptions(opts))
+}
+
+// decodeYAML stores the mapping held by node.
+func (c *CaseInsensitiveMap[T]) decodeYAML(node *yaml.Node, o decodeOptions) error {
+	if err := o.validate(c); err != nil {
+		return err
+	}
+
+	node = resolveYAML(node)
+	c.reset(max(o.sizeHint, len(node.Content)/2))
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return &DecodeError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("expected mapping, found %s", node.Tag)}
+	}
+
+	pairs, err := yamlPairs(node, c.equalFold)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		keyNode, valNode := pairs[i], pairs[i+1]
+
+		var val T
+		if o.nested {
+			nested, err := decodeNestedYAML(valNode, o, c)
+			if err != nil {
+				return err
+			}
+			// T is any here, so only a null value fails the assertion and stays nil
+			val, _ = nested.(T)
+		} else if err := valNode.Decode(&val); err != nil {
+			return &DecodeError{Line: valNode.Line, Column: valNode.Column, Key: keyNode.Value, Err: err}
+		}
+		if err := c.addWithPolicy(keyNode.Value, val, o.collision); err != nil {
+			return &DecodeError{Line: keyNode.Line, Column: keyNode.Column, Key: keyNode.Value, Err: err}
+		}
+	}
+	return nil
+}
+
+// decodeNestedYAML converts node into a value, turning mappings into maps that
+// inherit the folding of parent.
+func decodeNestedYAML[T any](node *yaml.Node, o decodeOptions, parent *CaseInsensitiveMap[T]) (any, error) {
+	node = resolveYAML(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := newWithFolding[any](parent)
+		pairs, err := yamlPairs(node, m.equalFold)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			keyNode := pairs[i]
+			val, err := decodeNestedYAML(pairs[i+1], o, parent)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.addWithPolicy(keyNode.Value, val, o.collision); err != nil {
+				return nil, &DecodeError{Line: keyNode.Line, Column: keyNode.Column, Key: keyNode.Value, Err: err}
+			}
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		arr := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			val, err := decodeNestedYAML(item, o, parent)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		return arr, nil
+	}
+
+	var val any
+	if err := node.Decode(&val); err != nil {
+		return nil, &DecodeError{Line: node.Line, Column: node.Column, Err: err}
+	}
+	return val, nil
+}
+
+// yamlMaxMergeDepth bounds how deeply merge keys may pull in other mappings.
+const yamlMaxMergeDepth = 64
+
+// yamlPairs returns the alternating key and value nodes of a mapping with its
+// merge keys ("<<") expanded in place. Keys written in the mapping override
+// merged ones, and earlier mappings of a merged sequence override later ones.
+func yamlPairs(node *yaml.Node, equal func(string, string) bool) ([]*yaml.Node, error) {
+	return yamlMergePairs(node, equal, 0)
+}
+
+func yamlMergePairs(node *yaml.Node, equal func(string, string) bool, depth int) ([]*yaml.Node, error) {
+	if depth > yamlMaxMergeDepth {
+		return nil, &DecodeError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("merge keys nested deeper than %d", yamlMaxMergeDepth)}
+	}
+
+	var (
+		pairs    []*yaml.Node
+		explicit []string
+		merged   []bool // whether each pair came from a merge key
+	)
+	contains := func(keys []string, k string) bool {
+		return slices.ContainsFunc(keys, func(other string) bool { return equal(other, k) })
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		if keyNode.ShortTag() != "!!merge" {
+			pairs = append(pairs, keyNode, valNode)
+			merged = append(merged, false)
+			explicit = append(explicit, keyNode.Value)
+			continue
+		}
+
+		sources := []*yaml.Node{resolveYAML(valNode)}
+		if sources[0].Kind == yaml.SequenceNode {
+			sources = sources[0].Content
+		}
+		var seen []string
+		for _, src := range sources {
+			src = resolveYAML(src)
+			if src.Kind != yaml.MappingNode {
+				return nil, &DecodeError{Line: src.Line, Column: src.Column, Key: keyNode.Value, Err: fmt.Errorf("merge value must be a mapping or a sequence of mappings, found %s", src.ShortTag())}
+			}
+			sub, err := yamlMergePairs(src, equal, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			for j := 0; j+1 < len(sub); j += 2 {
+				if contains(seen, sub[j].Value) {
+					continue
+				}
+				seen = append(seen, sub[j].Value)
+				pairs = append(pairs, sub[j], sub[j+1])
+				merged = append(merged, true)
+			}
+		}
+	}
+
+	out := pairs[:0]
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if merged[i/2] && contains(explicit, pairs[i].Value) {
+			continue
+		}
+		out = append(out, pairs[i], pairs[i+1])
+	}
+	return out, nil
+}
+
+// resolveYAML unwraps document and alias nodes.
+func resolveYAML(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
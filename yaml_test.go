@@ -0,0 +1,189 @@
+package cimap_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/projectbarks/cimap"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalUnmarshalYAML(t *testing.T) {
+	t.Run("Round trip keeps casing and order", func(t *testing.T) {
+		m := cimap.New[int]()
+		m.Add("Zeta", 1)
+		m.Add("alpha", 2)
+		m.Add("MiD", 3)
+
+		out, err := yaml.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, "Zeta: 1\nalpha: 2\nMiD: 3\n", string(out))
+
+		var m2 cimap.CaseInsensitiveMap[int]
+		assert.NoError(t, yaml.Unmarshal(out, &m2))
+		assert.Equal(t, 3, m2.Len())
+		val, ok := m2.Get("mid")
+		assert.True(t, ok)
+		assert.Equal(t, 3, val)
+
+		again, err := yaml.Marshal(&m2)
+		assert.NoError(t, err)
+		assert.Equal(t, string(out), string(again))
+	})
+
+	t.Run("Struct field", func(t *testing.T) {
+		var cfg struct {
+			Env *cimap.CaseInsensitiveMap[string] `yaml:"env"`
+		}
+		assert.NoError(t, yaml.Unmarshal([]byte("env:\n  PATH: /bin\n  Home: /root\n"), &cfg))
+		val, ok := cfg.Env.Get("path")
+		assert.True(t, ok)
+		assert.Equal(t, "/bin", val)
+	})
+
+	t.Run("Type error", func(t *testing.T) {
+		var m cimap.CaseInsensitiveMap[int]
+		assert.Error(t, yaml.Unmarshal([]byte("a: one\n"), &m))
+		assert.Error(t, yaml.Unmarshal([]byte("- 1\n- 2\n"), &m))
+	})
+}
+
+func TestDecodeYAML(t *testing.T) {
+	input := "Server:\n  Port: 8080\n  port: 9090\nDebug: true\n"
+
+	tests := []struct {
+		name     string
+		opts     []cimap.DecodeOption
+		expected any
+	}{
+		{
+			name:     "Replace",
+			expected: 9090,
+		},
+		{
+			name:     "Keep first",
+			opts:     []cimap.DecodeOption{cimap.WithCollisionPolicy(cimap.CollisionKeepFirst)},
+			expected: 8080,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cimap.New[any]()
+			opts := append([]cimap.DecodeOption{cimap.DecodeNested()}, tt.opts...)
+			assert.NoError(t, cfg.DecodeYAML(strings.NewReader(input), opts...))
+
+			val, ok := cfg.Path("SERVER", "PORT")
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+
+	t.Run("Collision error reports position", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		err := cfg.DecodeYAML(strings.NewReader(input),
+			cimap.DecodeNested(), cimap.WithCollisionPolicy(cimap.CollisionError))
+		assert.ErrorIs(t, err, cimap.ErrKeyCollision)
+
+		var derr *cimap.DecodeError
+		assert.True(t, errors.As(err, &derr))
+		assert.Equal(t, "port", derr.Key)
+		assert.Equal(t, 3, derr.Line)
+		assert.Equal(t, 3, derr.Column)
+		assert.Contains(t, err.Error(), "line 3, column 3")
+	})
+
+	t.Run("Top level collision", func(t *testing.T) {
+		m := cimap.New[string]()
+		err := m.DecodeYAML(strings.NewReader("a: x\nA: y\n"), cimap.WithCollisionPolicy(cimap.CollisionError))
+		var derr *cimap.DecodeError
+		assert.True(t, errors.As(err, &derr))
+		assert.Equal(t, 2, derr.Line)
+	})
+
+	t.Run("Empty document", func(t *testing.T) {
+		m := cimap.New[string]()
+		m.Add("stale", "x")
+		assert.NoError(t, m.DecodeYAML(strings.NewReader("")))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("Null values", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		doc := "a: ~\nb:\nc:\n  d: null\n"
+		assert.NoError(t, cfg.DecodeYAML(strings.NewReader(doc), cimap.DecodeNested()))
+		assert.Equal(t, 3, cfg.Len())
+		for _, path := range [][]string{{"A"}, {"B"}, {"c", "D"}} {
+			val, ok := cfg.Path(path...)
+			assert.True(t, ok, "%v", path)
+			assert.Nil(t, val, "%v", path)
+		}
+	})
+
+	t.Run("Aliases", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		doc := "base: &base\n  Timeout: 5s\ncopy: *base\n"
+		assert.NoError(t, cfg.DecodeYAML(strings.NewReader(doc), cimap.DecodeNested()))
+		val, ok := cfg.Path("Copy", "timeout")
+		assert.True(t, ok)
+		assert.Equal(t, "5s", val)
+	})
+}
+
+func TestDecodeYAML_MergeKeys(t *testing.T) {
+	const doc = `
+base: &base {x: 1, Shared: base}
+other: &other {X: 9, z: 3, shared: other}
+child: {<<: *base, y: 2}
+override: {SHARED: own, <<: *base}
+list: {<<: [*base, *other]}
+nested: {<<: {<<: *base, w: 4}}
+`
+
+	tests := []struct {
+		name string
+		path []string
+		want any
+		ok   bool
+	}{
+		{name: "Merged key", path: []string{"child", "X"}, want: 1, ok: true},
+		{name: "Explicit key", path: []string{"child", "y"}, want: 2, ok: true},
+		{name: "No merge key left", path: []string{"child", "<<"}},
+		{name: "Explicit key wins", path: []string{"override", "shared"}, want: "own", ok: true},
+		{name: "Earlier mapping wins", path: []string{"list", "x"}, want: 1, ok: true},
+		{name: "Later mapping fills gaps", path: []string{"list", "Z"}, want: 3, ok: true},
+		{name: "Nested merge", path: []string{"nested", "x"}, want: 1, ok: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cimap.New[any]()
+			err := cfg.DecodeYAML(strings.NewReader(doc), cimap.DecodeNested(), cimap.WithCollisionPolicy(cimap.CollisionError))
+			assert.NoError(t, err)
+			val, ok := cfg.Path(tt.path...)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, val)
+		})
+	}
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		var doc struct {
+			Sub cimap.CaseInsensitiveMap[int]
+		}
+		assert.NoError(t, yaml.Unmarshal([]byte("base: &b {A: 1, b: 2}\nsub: {<<: *b, B: 3}\n"), &doc))
+		assert.Equal(t, 2, doc.Sub.Len())
+		assert.Equal(t, 1, doc.Sub.Lookup("a"))
+		assert.Equal(t, 3, doc.Sub.Lookup("b"))
+	})
+
+	t.Run("Invalid merge value", func(t *testing.T) {
+		cfg := cimap.New[any]()
+		err := cfg.DecodeYAML(strings.NewReader("a: {<<: 1}\n"), cimap.DecodeNested())
+		assert.ErrorContains(t, err, "merge value must be a mapping")
+	})
+}